@@ -10,6 +10,6 @@ import (
 func main() {
 	if err := app.Execute(); err != nil {
 		fmt.Fprintln(os.Stderr, err)
-		os.Exit(1)
+		os.Exit(app.ExitCode(err))
 	}
 } 
\ No newline at end of file
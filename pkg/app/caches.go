@@ -0,0 +1,135 @@
+package app
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/alexintosh/gocleaner/pkg/finder"
+	"github.com/alexintosh/gocleaner/pkg/units"
+	"github.com/spf13/cobra"
+)
+
+var (
+	cachesOlderThan string
+	cachesMinSize   string
+	cachesJSON      bool
+)
+
+func init() {
+	cachesCmd := &cobra.Command{
+		Use:   "caches",
+		Short: "List and clean cache directories of all applications",
+		Long: `List the entries of ~/Library/Caches and the per-user cache directory under
+/private/var/folders grouped by the app they belong to, with their size and age.
+With --older-than and/or --min-size, entries meeting every given threshold are deleted.`,
+		Args: cobra.NoArgs,
+		RunE: runCaches,
+	}
+
+	cachesCmd.Flags().StringVar(&cachesOlderThan, "older-than", "", "Delete entries not modified for this long (e.g. 12h, 30d, 2w)")
+	cachesCmd.Flags().StringVar(&cachesMinSize, "min-size", "", "Delete entries at least this large (e.g. 500K, 100MB, 1G)")
+	cachesCmd.Flags().BoolVar(&cachesJSON, "json", false, "Print the cache entries as JSON; cannot be combined with --older-than or --min-size")
+	addRemovalFlags(cachesCmd)
+
+	rootCmd.AddCommand(cachesCmd)
+}
+
+func runCaches(cmd *cobra.Command, args []string) error {
+	var olderThan time.Duration
+	var minSize int64
+	var err error
+
+	// JSON only lists, so thresholds would silently delete nothing
+	if cachesJSON && (cachesOlderThan != "" || cachesMinSize != "") {
+		return errors.New("--json lists the cache entries and cannot be combined with --older-than or --min-size")
+	}
+
+	if cachesOlderThan != "" {
+		if olderThan, err = units.ParseAge(cachesOlderThan); err != nil {
+			return err
+		}
+	}
+	if cachesMinSize != "" {
+		if minSize, err = units.ParseBytes(cachesMinSize); err != nil {
+			return err
+		}
+	}
+
+	apps, err := finder.ListApps()
+	if err != nil {
+		return fmt.Errorf("error listing applications: %w", err)
+	}
+	entries := finder.ScanCaches(apps)
+
+	if cachesJSON {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(entries)
+	}
+
+	if len(entries) == 0 {
+		fmt.Println("No cache entries found")
+		return nil
+	}
+
+	// Without thresholds the command only reports
+	pruning := cachesOlderThan != "" || cachesMinSize != ""
+	now := time.Now()
+	selected := []string{}
+	var selectedSize int64
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "OWNER\tENTRY\tSIZE\tAGE\t")
+	for _, e := range entries {
+		age := now.Sub(e.Modified)
+		mark := ""
+		if pruning && age >= olderThan && e.Size >= minSize {
+			selected = append(selected, e.Path)
+			selectedSize += e.Size
+			mark = "delete"
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n", e.Owner, e.Path, units.FormatBytes(e.Size), units.FormatAge(age), mark)
+	}
+	w.Flush()
+
+	if !pruning {
+		return nil
+	}
+	if len(selected) == 0 {
+		fmt.Println("\nNo cache entries match the given thresholds.")
+		return nil
+	}
+
+	fmt.Printf("\n%d cache entries (%s) match the given thresholds.\n", len(selected), units.FormatBytes(selectedSize))
+
+	// If dry run, exit here
+	if dryRun {
+		fmt.Println("This was a dry run. No files were deleted.")
+		return nil
+	}
+
+	// Confirm deletion unless force flag is set
+	if !force {
+		fmt.Print("\nAre you sure you want to delete these cache entries? (y/N): ")
+		var confirm string
+		fmt.Scanln(&confirm)
+		if strings.ToLower(confirm) != "y" {
+			fmt.Println("Operation cancelled.")
+			return nil
+		}
+	}
+
+	deleted, foreign, err := deletePaths(selected)
+	if err != nil {
+		return fmt.Errorf("error deleting files: %w", err)
+	}
+	printForeignOwned(foreign)
+
+	fmt.Printf("\nSuccessfully deleted %d cache entries.\n", deleted)
+	return nil
+}
@@ -0,0 +1,23 @@
+package app
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestJSONRefusesDeletionOptions(t *testing.T) {
+	t.Cleanup(func() {
+		cachesJSON, cachesOlderThan, cachesMinSize = false, "", ""
+		logsJSON, logsKeepLast, logsOlderThan = false, -1, ""
+	})
+
+	cachesJSON, cachesMinSize = true, "100MB"
+	if err := runCaches(nil, nil); err == nil || !strings.Contains(err.Error(), "cannot be combined") {
+		t.Errorf("caches --json --min-size: error = %v, want a refusal", err)
+	}
+
+	logsJSON, logsKeepLast = true, 0
+	if err := runLogs(nil, nil); err == nil || !strings.Contains(err.Error(), "cannot be combined") {
+		t.Errorf("logs --json --keep-last 0: error = %v, want a refusal", err)
+	}
+}
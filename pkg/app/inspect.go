@@ -0,0 +1,167 @@
+package app
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/alexintosh/gocleaner/pkg/finder"
+	"github.com/alexintosh/gocleaner/pkg/units"
+	"github.com/spf13/cobra"
+)
+
+var inspectJSON bool
+
+// inspection is everything nuke knows about an app, as printed by inspect
+type inspection struct {
+	App       *finder.App            `json:"app,omitempty"`
+	Info      map[string]interface{} `json:"info,omitempty"`
+	Helpers   []finder.Helper        `json:"helpers,omitempty"`
+	Signature *finder.Signature      `json:"signature,omitempty"`
+	Leftovers []inspectedMatch       `json:"leftovers"`
+}
+
+type inspectedMatch struct {
+	finder.Match
+	Size int64 `json:"size"`
+}
+
+func init() {
+	inspectCmd := &cobra.Command{
+		Use:   "inspect <AppName>",
+		Short: "Show an app's metadata and the files an uninstall would remove",
+		Long: `Show everything nuke knows about an application without deleting anything:
+its Info.plist, nested helper bundles, code signature, location and every
+associated file grouped by category, with sizes and the reason it matched.`,
+		Args: cobra.ExactArgs(1),
+		RunE: runInspect,
+	}
+
+	inspectCmd.Flags().BoolVar(&inspectJSON, "json", false, "Print the inspection as JSON")
+
+	rootCmd.AddCommand(inspectCmd)
+}
+
+func runInspect(cmd *cobra.Command, args []string) error {
+	appName := strings.TrimSuffix(args[0], ".app")
+
+	matches, err := finder.NewAppFinder(false).FindMatches(appName)
+	if err != nil {
+		return fmt.Errorf("error finding files: %w", err)
+	}
+
+	result := inspection{Leftovers: []inspectedMatch{}}
+	for _, m := range matches {
+		if m.Category == finder.CategoryApplication && result.App == nil {
+			app := finder.ReadApp(m.Path, m.Root)
+			result.App = &app
+			result.Info, _ = finder.ReadInfoPlist(m.Path)
+			result.Helpers = finder.FindHelpers(m.Path)
+			sig := finder.ReadSignature(m.Path)
+			result.Signature = &sig
+			continue
+		}
+		result.Leftovers = append(result.Leftovers, inspectedMatch{Match: m, Size: finder.PathSize(m.Path)})
+	}
+
+	if inspectJSON {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(result)
+	}
+
+	if result.App == nil && len(result.Leftovers) == 0 {
+		fmt.Printf("No files found for %s\n", appName)
+		return nil
+	}
+
+	printInspection(appName, result)
+	return nil
+}
+
+// printInspection renders an inspection as human readable text
+func printInspection(appName string, result inspection) {
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+
+	if a := result.App; a != nil {
+		fmt.Fprintf(w, "%s\n\n", a.Name)
+		fmt.Fprintf(w, "Bundle ID:\t%s\n", a.BundleID)
+		fmt.Fprintf(w, "Version:\t%s\n", a.Version)
+		fmt.Fprintf(w, "Bundle:\t%s (%s)\n", a.Path, units.FormatBytes(a.Size))
+		fmt.Fprintf(w, "Location:\t%s\n", a.Location)
+		fmt.Fprintf(w, "Source:\t%s\n", a.Source)
+		switch sig := result.Signature; {
+		case !sig.Signed:
+			fmt.Fprintf(w, "Signature:\tunsigned or unavailable\n")
+		case sig.TeamID != "":
+			fmt.Fprintf(w, "Signature:\tteam %s\n", sig.TeamID)
+		default:
+			fmt.Fprintf(w, "Signature:\tsigned, no team\n")
+		}
+		for _, authority := range result.Signature.Authority {
+			fmt.Fprintf(w, "\t%s\n", authority)
+		}
+
+		if len(result.Info) > 0 {
+			fmt.Fprintf(w, "\nInfo.plist:\n")
+			keys := make([]string, 0, len(result.Info))
+			for key := range result.Info {
+				keys = append(keys, key)
+			}
+			sort.Strings(keys)
+			for _, key := range keys {
+				fmt.Fprintf(w, "  %s\t%s\n", key, formatPlistValue(result.Info[key]))
+			}
+		}
+
+		if len(result.Helpers) > 0 {
+			fmt.Fprintf(w, "\nHelpers:\n")
+			for _, h := range result.Helpers {
+				fmt.Fprintf(w, "  %s\t%s\n", h.BundleID, h.Path)
+			}
+		}
+	} else {
+		fmt.Fprintf(w, "%s\n\nNo app bundle found\n", appName)
+	}
+
+	var total int64
+	for _, m := range result.Leftovers {
+		total += m.Size
+	}
+	fmt.Fprintf(w, "\nLeftovers (%d items, %s):\n", len(result.Leftovers), units.FormatBytes(total))
+
+	for _, category := range finder.Categories {
+		var group []inspectedMatch
+		var size int64
+		for _, m := range result.Leftovers {
+			if m.Category == category {
+				group = append(group, m)
+				size += m.Size
+			}
+		}
+		if len(group) == 0 {
+			continue
+		}
+
+		fmt.Fprintf(w, "  %s (%d, %s)\n", category, len(group), units.FormatBytes(size))
+		for _, m := range group {
+			fmt.Fprintf(w, "    %s\t%s\t%s, %s confidence\n", m.Path, units.FormatBytes(m.Size), m.Reason, m.Confidence)
+		}
+	}
+
+	w.Flush()
+}
+
+// formatPlistValue renders a plist value on a single line
+func formatPlistValue(value interface{}) string {
+	switch v := value.(type) {
+	case []interface{}:
+		return fmt.Sprintf("[%d items]", len(v))
+	case map[string]interface{}:
+		return fmt.Sprintf("{%d keys}", len(v))
+	}
+	return fmt.Sprint(value)
+}
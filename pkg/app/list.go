@@ -0,0 +1,129 @@
+package app
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/alexintosh/gocleaner/pkg/finder"
+	"github.com/alexintosh/gocleaner/pkg/units"
+	"github.com/spf13/cobra"
+)
+
+var (
+	listSort    string
+	listReverse bool
+	listSource  string
+	listFilter  string
+	listJSON    bool
+)
+
+func init() {
+	listCmd := &cobra.Command{
+		Use:   "list",
+		Short: "List installed applications and their leftovers",
+		Long: `List every app bundle found in the application folders together with its
+bundle ID, version, size, install source and the leftovers nuke would remove.`,
+		Args: cobra.NoArgs,
+		RunE: runList,
+	}
+
+	listCmd.Flags().StringVar(&listSort, "sort", "name", "Sort by name, size, leftovers or source")
+	listCmd.Flags().BoolVar(&listReverse, "reverse", false, "Reverse the sort order")
+	listCmd.Flags().StringVar(&listSource, "source", "", "Only show apps from this install source (app-store, homebrew, manual)")
+	listCmd.Flags().StringVar(&listFilter, "filter", "", "Only show apps whose name or bundle ID contains this text")
+	listCmd.Flags().BoolVar(&listJSON, "json", false, "Print the inventory as JSON")
+
+	rootCmd.AddCommand(listCmd)
+}
+
+func runList(cmd *cobra.Command, args []string) error {
+	less, err := appSorter(listSort)
+	if err != nil {
+		return err
+	}
+
+	switch finder.InstallSource(listSource) {
+	case "", finder.SourceAppStore, finder.SourceHomebrew, finder.SourceManual:
+	default:
+		return fmt.Errorf("unknown install source %q (expected app-store, homebrew or manual)", listSource)
+	}
+
+	apps, err := finder.ListApps()
+	if err != nil {
+		return fmt.Errorf("error listing applications: %w", err)
+	}
+	apps = filterApps(apps, finder.InstallSource(listSource), listFilter)
+
+	// Leftovers are matched in a single pass over the Library for all apps
+	leftovers := finder.NewAppFinder(false).FindLeftovers(apps)
+	for i := range apps {
+		apps[i].Leftovers = append([]string{}, leftovers[apps[i].Path]...)
+		for _, path := range apps[i].Leftovers {
+			apps[i].LeftoverSize += finder.PathSize(path)
+		}
+	}
+
+	sort.SliceStable(apps, func(i, j int) bool {
+		if listReverse {
+			return less(apps[j], apps[i])
+		}
+		return less(apps[i], apps[j])
+	})
+
+	if listJSON {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(apps)
+	}
+
+	if len(apps) == 0 {
+		fmt.Println("No applications found")
+		return nil
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "NAME\tBUNDLE ID\tVERSION\tSIZE\tSOURCE\tLEFTOVERS\tLOCATION")
+	for _, a := range apps {
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%d (%s)\t%s\n",
+			a.Name, a.BundleID, a.Version, units.FormatBytes(a.Size), a.Source,
+			len(a.Leftovers), units.FormatBytes(a.LeftoverSize), a.Location)
+	}
+	return w.Flush()
+}
+
+// appSorter returns the comparison used for the --sort flag
+func appSorter(key string) (func(a, b finder.App) bool, error) {
+	switch key {
+	case "name":
+		return func(a, b finder.App) bool {
+			return strings.ToLower(a.Name) < strings.ToLower(b.Name)
+		}, nil
+	case "size":
+		return func(a, b finder.App) bool { return a.Size > b.Size }, nil
+	case "leftovers":
+		return func(a, b finder.App) bool { return a.LeftoverSize > b.LeftoverSize }, nil
+	case "source":
+		return func(a, b finder.App) bool { return a.Source < b.Source }, nil
+	}
+	return nil, fmt.Errorf("unknown sort key %q (expected name, size, leftovers or source)", key)
+}
+
+// filterApps keeps the apps matching the install source and text filter
+func filterApps(apps []finder.App, source finder.InstallSource, text string) []finder.App {
+	text = strings.ToLower(text)
+	filtered := []finder.App{}
+	for _, a := range apps {
+		if source != "" && a.Source != source {
+			continue
+		}
+		if text != "" && !strings.Contains(strings.ToLower(a.Name), text) && !strings.Contains(strings.ToLower(a.BundleID), text) {
+			continue
+		}
+		filtered = append(filtered, a)
+	}
+	return filtered
+}
@@ -0,0 +1,144 @@
+package app
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/alexintosh/gocleaner/pkg/finder"
+	"github.com/alexintosh/gocleaner/pkg/units"
+	"github.com/spf13/cobra"
+)
+
+var (
+	logsKeepLast  int
+	logsOlderThan string
+	logsApp       string
+	logsJSON      bool
+)
+
+func init() {
+	logsCmd := &cobra.Command{
+		Use:   "logs",
+		Short: "List and prune log files and crash reports",
+		Long: `List the files in ~/Library/Logs and ~/Library/Logs/DiagnosticReports grouped by
+the app they belong to. With --keep-last and/or --older-than, entries outside
+every given retention limit are deleted.`,
+		Args: cobra.NoArgs,
+		RunE: runLogs,
+	}
+
+	logsCmd.Flags().IntVar(&logsKeepLast, "keep-last", -1, "Keep only the newest N entries per app")
+	logsCmd.Flags().StringVar(&logsOlderThan, "older-than", "", "Delete entries not modified for this long (e.g. 12h, 30d, 2w)")
+	logsCmd.Flags().StringVar(&logsApp, "app", "", "Only consider entries belonging to this app")
+	logsCmd.Flags().BoolVar(&logsJSON, "json", false, "Print the log entries as JSON; cannot be combined with --keep-last or --older-than")
+	addRemovalFlags(logsCmd)
+
+	rootCmd.AddCommand(logsCmd)
+}
+
+func runLogs(cmd *cobra.Command, args []string) error {
+	// JSON only lists, so retention limits would silently delete nothing
+	if logsJSON && (logsKeepLast >= 0 || logsOlderThan != "") {
+		return errors.New("--json lists the log entries and cannot be combined with --keep-last or --older-than")
+	}
+
+	var olderThan time.Duration
+	if logsOlderThan != "" {
+		var err error
+		if olderThan, err = units.ParseAge(logsOlderThan); err != nil {
+			return err
+		}
+	}
+
+	apps, err := finder.ListApps()
+	if err != nil {
+		return fmt.Errorf("error listing applications: %w", err)
+	}
+
+	entries := []finder.LogEntry{}
+	for _, e := range finder.ScanLogs(apps) {
+		if logsApp == "" || strings.EqualFold(e.Owner, logsApp) || strings.EqualFold(e.BundleID, logsApp) {
+			entries = append(entries, e)
+		}
+	}
+
+	if logsJSON {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(entries)
+	}
+
+	if len(entries) == 0 {
+		fmt.Println("No log files found")
+		return nil
+	}
+
+	// Without retention limits the command only reports
+	pruning := logsKeepLast >= 0 || logsOlderThan != ""
+	now := time.Now()
+	selected := []string{}
+	var selectedSize int64
+
+	// Entries are grouped by owner, newest first, so the position within
+	// the group tells whether keep-last protects an entry
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "OWNER\tKIND\tENTRY\tSIZE\tAGE\t")
+	owner, position := "", 0
+	for _, e := range entries {
+		if e.Owner != owner {
+			owner, position = e.Owner, 0
+		}
+		position++
+
+		age := now.Sub(e.Modified)
+		mark := ""
+		if pruning && (logsKeepLast < 0 || position > logsKeepLast) && age >= olderThan {
+			selected = append(selected, e.Path)
+			selectedSize += e.Size
+			mark = "delete"
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\n", e.Owner, e.Kind, e.Path, units.FormatBytes(e.Size), units.FormatAge(age), mark)
+	}
+	w.Flush()
+
+	if !pruning {
+		return nil
+	}
+	if len(selected) == 0 {
+		fmt.Println("\nNo log files fall outside the given retention limits.")
+		return nil
+	}
+
+	fmt.Printf("\n%d log entries (%s) fall outside the given retention limits.\n", len(selected), units.FormatBytes(selectedSize))
+
+	// If dry run, exit here
+	if dryRun {
+		fmt.Println("This was a dry run. No files were deleted.")
+		return nil
+	}
+
+	// Confirm deletion unless force flag is set
+	if !force {
+		fmt.Print("\nAre you sure you want to delete these log entries? (y/N): ")
+		var confirm string
+		fmt.Scanln(&confirm)
+		if strings.ToLower(confirm) != "y" {
+			fmt.Println("Operation cancelled.")
+			return nil
+		}
+	}
+
+	deleted, foreign, err := deletePaths(selected)
+	if err != nil {
+		return fmt.Errorf("error deleting files: %w", err)
+	}
+	printForeignOwned(foreign)
+
+	fmt.Printf("\nSuccessfully deleted %d log entries.\n", deleted)
+	return nil
+}
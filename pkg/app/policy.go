@@ -0,0 +1,53 @@
+package app
+
+import (
+	"fmt"
+	"path/filepath"
+
+	"github.com/alexintosh/gocleaner/pkg/cleaner"
+	"github.com/alexintosh/gocleaner/pkg/policy"
+	"github.com/spf13/cobra"
+)
+
+var policyBundleIDs []string
+
+func init() {
+	policyCmd := &cobra.Command{
+		Use:   "policy",
+		Short: "Inspect the rules that decide what nuke may delete",
+	}
+
+	checkCmd := &cobra.Command{
+		Use:   "check <path>",
+		Short: "Explain whether a path may be deleted and which rule decided",
+		Args:  cobra.ExactArgs(1),
+		RunE:  runPolicyCheck,
+	}
+	checkCmd.Flags().StringSliceVar(&policyBundleIDs, "bundle-id", nil, "Evaluate the path as belonging to these bundle IDs")
+
+	policyCmd.AddCommand(checkCmd)
+	rootCmd.AddCommand(policyCmd)
+}
+
+func runPolicyCheck(cmd *cobra.Command, args []string) error {
+	path, err := filepath.Abs(args[0])
+	if err != nil {
+		return fmt.Errorf("invalid path: %w", err)
+	}
+
+	// Without explicit bundle IDs the check sees the path exactly as the cleaner would
+	var verdict policy.Verdict
+	if len(policyBundleIDs) > 0 {
+		verdict = policy.Evaluate(policy.SubjectFor(path, policyBundleIDs...))
+	} else {
+		verdict = cleaner.NewAppCleaner(false).Verdict(path)
+	}
+
+	if verdict.Allowed {
+		fmt.Printf("allowed: %s\n", path)
+	} else {
+		fmt.Printf("denied: %s\n", path)
+	}
+	fmt.Printf("  %s\n", verdict.Reason)
+	return nil
+}
@@ -0,0 +1,72 @@
+package app
+
+import (
+	"errors"
+	"os"
+
+	"github.com/alexintosh/gocleaner/pkg/cleaner"
+	"github.com/spf13/cobra"
+)
+
+// privilegedDeleteCommand is the hidden command nuke re-executes through sudo
+const privilegedDeleteCommand = "__privileged-delete"
+
+func init() {
+	privilegedCmd := &cobra.Command{
+		Use:    privilegedDeleteCommand,
+		Short:  "Delete root-owned items on behalf of an unprivileged nuke",
+		Hidden: true,
+		Args:   cobra.NoArgs,
+		RunE:   runPrivilegedDelete,
+	}
+	addOwnerFlag(privilegedCmd)
+
+	rootCmd.AddCommand(privilegedCmd)
+}
+
+// runPrivilegedDelete reads the paths to delete from stdin and reports the
+// outcome of each as NDJSON on stdout. Every path is checked again against
+// the system policy and the built-in rules; the user's configuration file is
+// never read here.
+func runPrivilegedDelete(cmd *cobra.Command, args []string) error {
+	if os.Geteuid() != 0 {
+		return errors.New(privilegedDeleteCommand + " must run as root")
+	}
+	c := cleaner.NewAppCleaner(false)
+	c.SetAllowForeignOwner(allowForeignOwner)
+	return c.ServePrivileged(os.Stdin, os.Stdout)
+}
+
+// newCleaner creates a cleaner that hands items needing root to nuke
+// re-executed through sudo. Interactive runs may let sudo ask for a password.
+func newCleaner(verbose, interactive bool) *cleaner.AppCleaner {
+	c := cleaner.NewAppCleaner(verbose)
+	c.SetAllowForeignOwner(allowForeignOwner)
+	if deleter := privilegedDeleter(interactive); deleter != nil {
+		c.SetPrivilegedDeleter(deleter)
+	}
+	return c
+}
+
+// privilegedDeleter returns a deleter running this executable through sudo
+// with the same target, or nil when it cannot be found. The configuration is
+// not passed on, since the helper ignores it.
+func privilegedDeleter(interactive bool) cleaner.PrivilegedDeleter {
+	exe, err := os.Executable()
+	if err != nil {
+		return nil
+	}
+
+	argv := []string{exe}
+	if rootDir != "" {
+		argv = append(argv, "--root", rootDir)
+	}
+	if userName != "" {
+		argv = append(argv, "--user", userName)
+	}
+	argv = append(argv, privilegedDeleteCommand)
+	if allowForeignOwner {
+		argv = append(argv, "--allow-foreign-owner")
+	}
+	return cleaner.SudoDeleter(argv, interactive)
+}
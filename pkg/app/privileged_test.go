@@ -0,0 +1,79 @@
+package app
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/alexintosh/gocleaner/pkg/config"
+	"github.com/alexintosh/gocleaner/pkg/paths"
+	"github.com/alexintosh/gocleaner/pkg/policy"
+)
+
+func TestPrivilegedHelperIgnoresUserConfig(t *testing.T) {
+	fixture, err := filepath.EvalSymlinks(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.MkdirAll(filepath.Join(fixture, "Users", "alice"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+
+	// The user's configuration allows a path no built-in rule allows
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("SUDO_USER", "")
+	target := filepath.Join(fixture, "opt", "tools", "x")
+	writeConfig(t, filepath.Join(home, ".config", "nuke", "config.toml"), "[policy]\nrules = [\"allow "+filepath.Join(fixture, "opt")+"/*\"]\n")
+
+	savedPaths := config.SystemPolicyPaths
+	config.SystemPolicyPaths = nil
+	t.Cleanup(func() {
+		config.SystemPolicyPaths = savedPaths
+		rootDir, userName, configPath = "", "", ""
+		paths.SetRoot("/")
+	})
+	rootDir, userName = fixture, "alice"
+
+	helper, _, err := rootCmd.Find([]string{privilegedDeleteCommand})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := loadConfig(helper, nil); err != nil {
+		t.Fatal(err)
+	}
+	if verdict := policy.Evaluate(policy.SubjectFor(target)); verdict.Allowed {
+		t.Fatalf("helper allowed %s: %s", target, verdict.Reason)
+	}
+
+	// A configuration file given to the helper directly is refused
+	configPath = filepath.Join(home, ".config", "nuke", "config.toml")
+	if err := loadConfig(helper, nil); err == nil || !strings.Contains(err.Error(), "does not read a configuration file") {
+		t.Fatalf("helper accepted --config: %v", err)
+	}
+	configPath = ""
+
+	// Any other command applies the rule
+	list, _, err := rootCmd.Find([]string{"list"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := loadConfig(list, nil); err != nil {
+		t.Fatal(err)
+	}
+	if verdict := policy.Evaluate(policy.SubjectFor(target)); !verdict.Allowed {
+		t.Fatalf("configured rule was not applied outside the helper: %s", verdict.Reason)
+	}
+}
+
+// writeConfig writes a configuration file, creating its folder
+func writeConfig(t *testing.T, path, content string) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+}
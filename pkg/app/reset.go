@@ -0,0 +1,83 @@
+package app
+
+import (
+	"github.com/alexintosh/gocleaner/pkg/finder"
+	"github.com/spf13/cobra"
+)
+
+var (
+	resetCaches     bool
+	resetPrefs      bool
+	resetState      bool
+	resetContainers bool
+	resetLogs       bool
+	resetSupport    bool
+	resetAll        bool
+)
+
+func init() {
+	resetCmd := &cobra.Command{
+		Use:   "reset <AppName>",
+		Short: "Delete an application's data but keep the application installed",
+		Long: `Reset an application to a clean state by deleting its caches, preferences and
+other data while leaving the app bundle in place. Without category flags the
+caches, preferences and saved state are removed.`,
+		Args: cobra.ExactArgs(1),
+		RunE: runReset,
+	}
+
+	addRemovalFlags(resetCmd)
+	addInterfaceFlags(resetCmd)
+	resetCmd.Flags().BoolVar(&resetCaches, "caches", false, "Delete cached data")
+	resetCmd.Flags().BoolVar(&resetPrefs, "prefs", false, "Delete preferences")
+	resetCmd.Flags().BoolVar(&resetState, "state", false, "Delete saved application state")
+	resetCmd.Flags().BoolVar(&resetContainers, "containers", false, "Delete sandbox containers, including any documents stored in them")
+	resetCmd.Flags().BoolVar(&resetLogs, "logs", false, "Delete logs")
+	resetCmd.Flags().BoolVar(&resetSupport, "support", false, "Delete files in Application Support")
+	resetCmd.Flags().BoolVar(&resetAll, "all", false, "Delete everything except the app bundle")
+
+	rootCmd.AddCommand(resetCmd)
+}
+
+func runReset(cmd *cobra.Command, args []string) error {
+	scanCategories = resetCategories()
+	return runRemoval(args)
+}
+
+// resetCategories returns the categories chosen with the reset flags. The app
+// bundle itself is never part of a reset.
+func resetCategories() []finder.Category {
+	if resetAll {
+		categories := []finder.Category{}
+		for _, c := range finder.Categories {
+			if c != finder.CategoryApplication {
+				categories = append(categories, c)
+			}
+		}
+		return categories
+	}
+
+	chosen := []struct {
+		set      bool
+		category finder.Category
+	}{
+		{resetSupport, finder.CategorySupport},
+		{resetPrefs, finder.CategoryPreferences},
+		{resetCaches, finder.CategoryCaches},
+		{resetLogs, finder.CategoryLogs},
+		{resetContainers, finder.CategoryContainers},
+		{resetState, finder.CategoryState},
+	}
+
+	categories := []finder.Category{}
+	for _, c := range chosen {
+		if c.set {
+			categories = append(categories, c.category)
+		}
+	}
+
+	if len(categories) == 0 {
+		return []finder.Category{finder.CategoryPreferences, finder.CategoryCaches, finder.CategoryState}
+	}
+	return categories
+}
@@ -1,17 +1,84 @@
 package app
 
 import (
+	"errors"
 	"fmt"
 	"os"
+	"os/user"
+	"strconv"
 
+	"github.com/alexintosh/gocleaner/pkg/config"
+	"github.com/alexintosh/gocleaner/pkg/finder"
+	"github.com/alexintosh/gocleaner/pkg/paths"
+	"github.com/alexintosh/gocleaner/pkg/policy"
+	"github.com/alexintosh/gocleaner/pkg/tui"
 	"github.com/spf13/cobra"
 )
 
+var (
+	configPath string
+	rootDir    string
+	userName   string
+)
+
+// systemPolicyMessage is shown when the system policy refuses an operation
+var systemPolicyMessage string
+
+// keyMap holds the TUI key bindings from the configuration file; nil keeps the defaults
+var keyMap *tui.KeyMap
+
+// Exit codes other than the generic 1
+const (
+	// exitProtected means the system policy refused to touch an app
+	exitProtected = 3
+)
+
+// ExitError is an error that ends the process with a specific exit code
+type ExitError struct {
+	Code int
+	Err  error
+}
+
+func (e *ExitError) Error() string { return e.Err.Error() }
+
+func (e *ExitError) Unwrap() error { return e.Err }
+
+// ExitCode returns the process exit code for an error returned by Execute
+func ExitCode(err error) int {
+	var exitErr *ExitError
+	if errors.As(err, &exitErr) {
+		return exitErr.Code
+	}
+	return 1
+}
+
 var rootCmd = &cobra.Command{
 	Use:   "nuke",
 	Short: "A CLI tool to fully uninstall macOS applications",
 	Long: `nuke is a command-line tool for macOS that helps users fully uninstall applications 
-by removing the main app bundle and associated files like caches, preferences, logs, etc.`,
+by removing the main app bundle and associated files like caches, preferences, logs, etc.
+Run without a command to pick the applications to uninstall from a list.`,
+	Args:              cobra.NoArgs,
+	PersistentPreRunE: loadConfig,
+	RunE:              runPicker,
+}
+
+func init() {
+	rootCmd.PersistentFlags().StringVar(&configPath, "config", "", "Configuration file (default ~/.config/nuke/config.toml)")
+	rootCmd.PersistentFlags().StringVar(&rootDir, "root", "", "Operate on a different root directory, e.g. a mounted disk image")
+	rootCmd.PersistentFlags().StringVar(&userName, "user", "", "Clean the home directory of another user (requires root unless used with --root)")
+
+	// nuke on its own uninstalls the apps picked in the TUI
+	addRemovalFlags(rootCmd)
+	addInterfaceFlags(rootCmd)
+}
+
+// runPicker opens the app picker, or the usage when the TUI is turned off
+func runPicker(cmd *cobra.Command, args []string) error {
+	if noTUI || eventsFormat != "" {
+		return cmd.Help()
+	}
+	return runUninstall(cmd, args)
 }
 
 // Execute adds all child commands to the root command and sets flags appropriately.
@@ -21,4 +88,88 @@ func Execute() error {
 		return err
 	}
 	return nil
-} 
\ No newline at end of file
+}
+
+// loadConfig reads the system policy and the configuration file and applies them to the finder, the
+// cleaner and the defaults of any flag not given on the command line
+func loadConfig(cmd *cobra.Command, args []string) error {
+	// Flags and arguments are valid by now, so usage would only bury the error
+	cmd.SilenceUsage = true
+
+	// The target has to be known before any path in the configuration is expanded
+	if err := applyTarget(); err != nil {
+		return err
+	}
+
+	// The administrator's policy is loaded first and regardless of --config
+	systemPolicy, err := config.LoadSystemPolicy()
+	if err != nil {
+		return err
+	}
+	policy.AddSystemRules(systemPolicy.Rules...)
+	systemPolicyMessage = systemPolicy.Message
+
+	// The root helper answers to the system policy and the built-in rules
+	// only, so the user running nuke cannot widen what root deletes
+	if cmd.Name() == privilegedDeleteCommand {
+		if configPath != "" {
+			return errors.New(privilegedDeleteCommand + " does not read a configuration file")
+		}
+		return nil
+	}
+
+	path, required := configPath, true
+	if path == "" {
+		path, required = config.DefaultPath(), false
+	}
+
+	cfg, err := config.Load(path, required)
+	if err != nil {
+		return err
+	}
+
+	finder.AddScanRoots(cfg.ScanRoots...)
+	finder.AddApplicationRoots(cfg.ApplicationRoots...)
+	finder.AddIgnorePatterns(cfg.Ignore...)
+
+	policy.AddRules(cfg.Rules...)
+
+	if len(cfg.Keys) > 0 {
+		keys := tui.DefaultKeyMap()
+		if err := keys.Override(cfg.Keys); err != nil {
+			return fmt.Errorf("%s: [keys]: %w", cfg.Path, err)
+		}
+		keyMap = &keys
+	}
+
+	for name, value := range cfg.Defaults {
+		if flag := cmd.Flags().Lookup(name); flag != nil && !flag.Changed {
+			if err := flag.Value.Set(strconv.FormatBool(value)); err != nil {
+				return fmt.Errorf("%s: invalid default for --%s: %w", cfg.Path, name, err)
+			}
+		}
+	}
+
+	return nil
+}
+
+// applyTarget rebases scanning and the policy onto --root and works out whose
+// home is cleaned from --user or, failing that, from sudo
+func applyTarget() error {
+	if rootDir != "" {
+		if err := paths.SetRoot(rootDir); err != nil {
+			return err
+		}
+	}
+	if userName == "" {
+		return paths.ResolveHome()
+	}
+
+	// Another account's files on the running system can only be removed as root
+	if paths.Root() == "/" && os.Geteuid() != 0 {
+		if current, err := user.Current(); err != nil || current.Username != userName {
+			return fmt.Errorf("--user %s requires root privileges", userName)
+		}
+	}
+	return paths.SetUser(userName)
+}
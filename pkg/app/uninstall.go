@@ -1,73 +1,324 @@
 package app
 
 import (
+	"bufio"
+	"context"
+	"errors"
 	"fmt"
+	"os"
+	"os/signal"
+	"path/filepath"
 	"strings"
 
 	"github.com/alexintosh/gocleaner/pkg/cleaner"
+	"github.com/alexintosh/gocleaner/pkg/events"
 	"github.com/alexintosh/gocleaner/pkg/finder"
+	"github.com/alexintosh/gocleaner/pkg/policy"
 	"github.com/alexintosh/gocleaner/pkg/tui"
 	"github.com/spf13/cobra"
 )
 
 var (
-	dryRun  bool
-	force   bool
-	verbose bool
-	noTUI   bool
+	dryRun            bool
+	force             bool
+	verbose           bool
+	noTUI             bool
+	eventsFormat      string
+	fromFile          string
+	allowForeignOwner bool
+	onlyCategories    []string
+	excludeCategories []string
+	includePaths      []string
+
+	// scanCategories limits which kinds of files are searched for; nil means all
+	scanCategories []finder.Category
 )
 
 func init() {
 	uninstallCmd := &cobra.Command{
-		Use:   "uninstall <AppName>",
-		Short: "Uninstall an application and its associated files",
-		Long: `Uninstall an application by removing the main app bundle and associated files
-like caches, preferences, logs, etc. from various macOS system paths.`,
-		Args: cobra.ExactArgs(1),
+		Use:   "uninstall [AppName]...",
+		Short: "Uninstall applications and their associated files",
+		Long: `Uninstall one or more applications by removing the main app bundle and associated files
+like caches, preferences, logs, etc. from various macOS system paths. Without
+app names the TUI lists the installed applications to choose from.`,
+		Args: cobra.ArbitraryArgs,
 		RunE: runUninstall,
 	}
 
-	uninstallCmd.Flags().BoolVar(&dryRun, "dry-run", false, "Show what would be deleted, but don't delete")
-	uninstallCmd.Flags().BoolVar(&force, "force", false, "Skip confirmation prompt and delete files immediately")
-	uninstallCmd.Flags().BoolVar(&verbose, "verbose", false, "Show detailed scanning and deletion info")
-	uninstallCmd.Flags().BoolVar(&noTUI, "no-tui", false, "Disable the interactive terminal UI")
+	addRemovalFlags(uninstallCmd)
+	addInterfaceFlags(uninstallCmd)
+	uninstallCmd.Flags().StringVar(&fromFile, "from-file", "", "Read app names from a file, one per line")
+	uninstallCmd.Flags().StringSliceVar(&onlyCategories, "only", nil, "Only remove files in these categories (application, support, preferences, caches, logs, containers, state)")
+	uninstallCmd.Flags().StringSliceVar(&excludeCategories, "exclude", nil, "Keep files in these categories")
+	uninstallCmd.Flags().StringArrayVar(&includePaths, "include", nil, "Also delete this file or directory if the deletion policy allows it (can be repeated)")
 
 	rootCmd.AddCommand(uninstallCmd)
 }
 
+// addRemovalFlags registers the flags shared by every command that deletes
+// files
+func addRemovalFlags(cmd *cobra.Command) {
+	cmd.Flags().BoolVar(&dryRun, "dry-run", false, "Show what would be deleted, but don't delete")
+	cmd.Flags().BoolVar(&force, "force", false, "Skip confirmation prompt and delete files immediately")
+	cmd.Flags().BoolVar(&verbose, "verbose", false, "Show detailed scanning and deletion info")
+	addOwnerFlag(cmd)
+}
+
+// addInterfaceFlags registers the flags choosing between the TUI, plain
+// output and an event stream for commands that delete files found by the
+// finder
+func addInterfaceFlags(cmd *cobra.Command) {
+	cmd.Flags().BoolVar(&noTUI, "no-tui", false, "Disable the interactive terminal UI")
+	cmd.Flags().StringVar(&eventsFormat, "events", "", "Stream scan and delete events to stdout instead of using the UI (supported: ndjson)")
+}
+
+// addOwnerFlag registers --allow-foreign-owner on a command that deletes files
+func addOwnerFlag(cmd *cobra.Command) {
+	cmd.Flags().BoolVar(&allowForeignOwner, "allow-foreign-owner", false, "Also delete items owned by other users")
+}
+
 func runUninstall(cmd *cobra.Command, args []string) error {
-	appName := args[0]
-	
+	appNames := args
+	if fromFile != "" {
+		fileNames, err := readAppList(fromFile)
+		if err != nil {
+			return err
+		}
+		appNames = append(appNames, fileNames...)
+	}
+	if len(appNames) == 0 {
+		// The TUI lets the apps be picked from the installed ones instead
+		if noTUI || eventsFormat != "" {
+			return fmt.Errorf("no app given: pass app names as arguments or use --from-file")
+		}
+		picked, err := tui.PickApps(keyMap)
+		if err != nil {
+			return err
+		}
+		if len(picked) == 0 {
+			return nil
+		}
+		appNames = picked
+	}
+
+	categories, err := filterCategories(onlyCategories, excludeCategories)
+	if err != nil {
+		return err
+	}
+	scanCategories = categories
+
+	return runRemoval(appNames)
+}
+
+// runRemoval scans for and deletes the files of the given apps using the
+// event stream, the CLI or the TUI depending on the flags
+func runRemoval(appNames []string) error {
 	// Remove .app suffix if provided - we'll handle both cases in the finder
-	appName = strings.TrimSuffix(appName, ".app")
+	for i, appName := range appNames {
+		appNames[i] = strings.TrimSuffix(appName, ".app")
+	}
+
+	// Apps protected by the system policy are refused before anything is scanned
+	if err := checkSystemPolicy(appNames); err != nil {
+		return err
+	}
+
+	included, err := includedMatches()
+	if err != nil {
+		return err
+	}
+
+	// Event streams replace every other kind of output, including the prompt
+	if eventsFormat != "" {
+		if eventsFormat != "ndjson" {
+			return fmt.Errorf("unsupported events format %q (supported: ndjson)", eventsFormat)
+		}
+		if !dryRun && !force {
+			return fmt.Errorf("--events requires --dry-run or --force since it cannot prompt for confirmation")
+		}
+		return runEventsUninstall(appNames, included)
+	}
 
 	// If not using TUI, use the original CLI approach
 	if noTUI {
-		return runCLIUninstall(appName)
+		return runCLIUninstall(appNames, included)
 	}
 
 	// Otherwise, use the TUI
-	return tui.RunTUI(appName, dryRun, force, verbose)
+	return tui.RunTUI(appNames, tui.Options{
+		DryRun:     dryRun,
+		Force:      force,
+		Verbose:    verbose,
+		Categories: scanCategories,
+		Include:    included,
+		Keys:       keyMap,
+
+		AllowForeignOwner: allowForeignOwner,
+
+		// sudo cannot prompt for a password while the TUI owns the terminal
+		Privileged: privilegedDeleter(false),
+	})
+}
+
+// includedMatches resolves the --include paths. Each has to exist and be
+// allowed by the deletion policy, so a path that would only be skipped is
+// refused before anything is scanned.
+func includedMatches() ([]finder.Match, error) {
+	appCleaner := cleaner.NewAppCleaner(false)
+	matches := []finder.Match{}
+	for _, path := range includePaths {
+		abs, err := filepath.Abs(path)
+		if err != nil {
+			return nil, fmt.Errorf("--include %s: %w", path, err)
+		}
+		if _, err := os.Lstat(abs); err != nil {
+			return nil, fmt.Errorf("--include: %w", err)
+		}
+
+		verdict := appCleaner.Verdict(abs)
+		if !verdict.Allowed {
+			return nil, fmt.Errorf("--include %s: %s", abs, verdict.Reason)
+		}
+		if verbose && eventsFormat == "" {
+			fmt.Printf("Including %s: %s\n", abs, verdict.Reason)
+		}
+		matches = append(matches, finder.IncludedMatch(abs))
+	}
+	return matches, nil
+}
+
+// newFinder creates a finder configured from the command line flags
+func newFinder(verbose bool, handler events.Handler) *finder.AppFinder {
+	f := finder.NewAppFinder(verbose)
+	f.SetEventHandler(handler)
+	f.SetCategories(scanCategories)
+	return f
+}
+
+// checkSystemPolicy refuses to continue when the system policy protects one
+// of the apps, either by bundle ID or by the location of its bundle
+func checkSystemPolicy(appNames []string) error {
+	refusals := []string{}
+	for _, appName := range appNames {
+		path, bundleID, found := finder.LocateApp(appName)
+		if !found {
+			continue
+		}
+		if verdict, protected := policy.SystemVerdict(policy.SubjectFor(path, bundleID)); protected {
+			refusals = append(refusals, fmt.Sprintf("%s is protected: %s", appName, verdict.Reason))
+		}
+	}
+
+	if len(refusals) == 0 {
+		return nil
+	}
+
+	msg := "refusing to continue, the system policy protects:\n  " + strings.Join(refusals, "\n  ")
+	if systemPolicyMessage != "" {
+		msg += "\n" + systemPolicyMessage
+	}
+	return &ExitError{Code: exitProtected, Err: errors.New(msg)}
+}
+
+// filterCategories resolves the --only and --exclude flags into the list of
+// categories to scan, or nil when neither flag is set
+func filterCategories(only, exclude []string) ([]finder.Category, error) {
+	if len(only) == 0 && len(exclude) == 0 {
+		return nil, nil
+	}
+
+	included := finder.Categories
+	if len(only) > 0 {
+		included = []finder.Category{}
+		for _, name := range only {
+			c, err := finder.ParseCategory(name)
+			if err != nil {
+				return nil, fmt.Errorf("invalid --only: %w", err)
+			}
+			included = append(included, c)
+		}
+	}
+
+	excluded := map[finder.Category]bool{}
+	for _, name := range exclude {
+		c, err := finder.ParseCategory(name)
+		if err != nil {
+			return nil, fmt.Errorf("invalid --exclude: %w", err)
+		}
+		excluded[c] = true
+	}
+
+	categories := []finder.Category{}
+	for _, c := range included {
+		if !excluded[c] {
+			categories = append(categories, c)
+		}
+	}
+	if len(categories) == 0 {
+		return nil, fmt.Errorf("--only and --exclude leave no categories to scan")
+	}
+	return categories, nil
+}
+
+// readAppList reads app names from a file, one per line. Blank lines and
+// lines starting with # are ignored.
+func readAppList(path string) ([]string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("error reading app list: %w", err)
+	}
+	defer file.Close()
+
+	appNames := []string{}
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		appNames = append(appNames, line)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("error reading app list: %w", err)
+	}
+	return appNames, nil
 }
 
 // runCLIUninstall runs the original CLI-based uninstall process
-func runCLIUninstall(appName string) error {
-	// Find app bundle and associated files
-	appFinder := finder.NewAppFinder(verbose)
-	foundFiles, err := appFinder.FindAllAssociatedFiles(appName)
+func runCLIUninstall(appNames []string, included []finder.Match) error {
+	// Find app bundles and associated files for every app before deleting anything
+	results, conflicts, err := finder.FindBatch(appNames, func() *finder.AppFinder {
+		return newFinder(verbose, nil)
+	})
 	if err != nil {
 		return fmt.Errorf("error finding files: %w", err)
 	}
+	results = finder.IncludeMatches(results, included)
 
-	if len(foundFiles) == 0 {
-		fmt.Printf("No files found for %s\n", appName)
-		return nil
+	total := 0
+	for _, result := range results {
+		if len(result.Matches) == 0 {
+			fmt.Printf("No files found for %s\n", result.App)
+			continue
+		}
+
+		// Print found files
+		fmt.Printf("Found %d files associated with %s:\n", len(result.Matches), result.App)
+		for _, m := range result.Matches {
+			fmt.Printf("- %s\n", m.Path)
+		}
+		total += len(result.Matches)
+	}
+
+	if len(conflicts) > 0 {
+		fmt.Printf("\n%d paths are claimed by more than one app and will only be removed once:\n", len(conflicts))
+		for _, c := range conflicts {
+			fmt.Printf("- %s (%s)\n", c.Path, strings.Join(c.Apps, ", "))
+		}
 	}
 
-	// Print found files
-	fmt.Printf("Found %d files associated with %s:\n", len(foundFiles), appName)
-	for _, file := range foundFiles {
-		fmt.Printf("- %s\n", file)
+	if total == 0 {
+		return nil
 	}
 
 	// If dry run, exit here
@@ -87,13 +338,182 @@ func runCLIUninstall(appName string) error {
 		}
 	}
 
-	// Delete files
-	appCleaner := cleaner.NewAppCleaner(verbose)
-	deleted, err := appCleaner.DeleteFiles(foundFiles)
+	// Delete files; Ctrl+C stops after the current file
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+	totals, err := deleteBatch(ctx, results, verbose, true, func(e events.Event) {
+		if e.Type == events.ItemFailed {
+			fmt.Printf("Error deleting %s: %s\n", e.Path, e.Error)
+		}
+	})
 	if err != nil {
 		return fmt.Errorf("error deleting files: %w", err)
 	}
 
-	fmt.Printf("\nSuccessfully deleted %d files.\n", deleted)
+	if len(results) > 1 {
+		fmt.Println()
+		for _, result := range results {
+			t := totals.PerApp[result.App]
+			fmt.Printf("%s: %d deleted, %d skipped, %d failed\n", result.App, t.Deleted, t.Skipped, t.Failed)
+		}
+	}
+	printForeignOwned(totals.ForeignOwned)
+	if totals.Cancelled {
+		fmt.Printf("\nCancelled: deleted %d of %d files before stopping.\n", totals.Deleted, totals.Found)
+		return nil
+	}
+	fmt.Printf("\nSuccessfully deleted %d files.\n", totals.Deleted)
 	return nil
-} 
\ No newline at end of file
+}
+
+// runEventsUninstall runs the uninstall process without any UI, streaming
+// every scan and delete event to stdout as NDJSON
+func runEventsUninstall(appNames []string, included []finder.Match) error {
+	emit := events.NewNDJSONHandler(os.Stdout)
+
+	// Verbose output would interleave with the stream, so it is always off here
+	results, conflicts, err := finder.FindBatch(appNames, func() *finder.AppFinder {
+		return newFinder(false, emit)
+	})
+	if err != nil {
+		return fmt.Errorf("error finding files: %w", err)
+	}
+	for _, c := range conflicts {
+		emit.Emit(events.Event{Type: events.ConflictFound, Path: c.Path, Apps: c.Apps})
+	}
+
+	// Paths added with --include are reported like the ones the scan found
+	before := len(results[0].Matches)
+	results = finder.IncludeMatches(results, included)
+	for _, m := range results[0].Matches[before:] {
+		emit.Emit(events.Event{Type: events.MatchFound, App: results[0].App, Path: m.Path, Root: m.Root, Category: string(m.Category), Reason: m.Reason})
+	}
+
+	var totals *events.Totals
+	if dryRun {
+		totals = countBatch(results)
+	} else {
+		// An interrupt ends the stream with a summary marked as cancelled
+		ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+		defer stop()
+		// Nobody sees the terminal of a stream, so sudo must not prompt on it
+		totals, err = deleteBatch(ctx, results, false, false, emit)
+		if err != nil {
+			return fmt.Errorf("error deleting files: %w", err)
+		}
+	}
+	totals.DryRun = dryRun
+	for _, c := range scanCategories {
+		totals.Categories = append(totals.Categories, string(c))
+	}
+	for _, appTotals := range totals.PerApp {
+		appTotals.DryRun = dryRun
+	}
+
+	summary := events.Event{Type: events.Summary, Totals: totals}
+	if len(appNames) == 1 {
+		summary.App = appNames[0]
+	} else {
+		summary.Apps = appNames
+	}
+	emit.Emit(summary)
+	return nil
+}
+
+// countBatch tallies the files found per app without deleting anything
+func countBatch(results []finder.AppMatches) *events.Totals {
+	totals := &events.Totals{}
+	if len(results) > 1 {
+		totals.PerApp = map[string]*events.Totals{}
+	}
+
+	for _, result := range results {
+		totals.Found += len(result.Matches)
+		if totals.PerApp != nil {
+			totals.PerApp[result.App] = &events.Totals{Found: len(result.Matches)}
+		}
+	}
+	return totals
+}
+
+// deleteBatch deletes the files found for every app, forwarding each
+// deletion event to handler, and returns the outcome in total and per app.
+// Once ctx is cancelled it stops after the current file and marks the
+// totals as cancelled. Unless interactive, sudo may not ask for a password.
+func deleteBatch(ctx context.Context, results []finder.AppMatches, verbose, interactive bool, handler events.Handler) (*events.Totals, error) {
+	totals := countBatch(results)
+
+	for _, result := range results {
+		appTotals := &events.Totals{Found: len(result.Matches)}
+		if totals.PerApp != nil {
+			totals.PerApp[result.App] = appTotals
+		}
+		if len(result.Matches) == 0 {
+			continue
+		}
+
+		appName := result.App
+		appCleaner := newCleaner(verbose, interactive)
+		appCleaner.SetEventHandler(func(e events.Event) {
+			switch e.Type {
+			case events.ItemSkipped:
+				appTotals.Skipped++
+				if e.ForeignOwner {
+					appTotals.ForeignOwned = append(appTotals.ForeignOwned, e.Path)
+				}
+			case events.ItemFailed:
+				appTotals.Failed++
+			}
+			e.App = appName
+			handler.Emit(e)
+		})
+
+		deleted, err := appCleaner.DeleteFilesContext(ctx, finder.Paths(result.Matches))
+		cancelled := errors.Is(err, context.Canceled)
+		if err != nil && !cancelled {
+			return nil, err
+		}
+		appTotals.Deleted = deleted
+		appTotals.Cancelled = cancelled
+
+		totals.Deleted += appTotals.Deleted
+		totals.Skipped += appTotals.Skipped
+		totals.Failed += appTotals.Failed
+		totals.ForeignOwned = append(totals.ForeignOwned, appTotals.ForeignOwned...)
+		if cancelled {
+			totals.Cancelled = true
+			break
+		}
+	}
+
+	return totals, nil
+}
+
+// deletePaths deletes files outside of any app, printing every failure, and
+// returns how many were deleted and which were skipped for their owner
+func deletePaths(files []string) (int, []string, error) {
+	foreign := []string{}
+	c := newCleaner(verbose, true)
+	c.SetEventHandler(func(e events.Event) {
+		switch {
+		case e.ForeignOwner:
+			foreign = append(foreign, e.Path)
+		case e.Type == events.ItemFailed:
+			fmt.Printf("Error deleting %s: %s\n", e.Path, e.Error)
+		}
+	})
+
+	deleted, err := c.DeleteFiles(files)
+	return deleted, foreign, err
+}
+
+// printForeignOwned lists the items skipped because another user owns them
+func printForeignOwned(items []string) {
+	if len(items) == 0 {
+		return
+	}
+	fmt.Printf("\n%d items were skipped because another user owns them (use --allow-foreign-owner to delete them):\n", len(items))
+	for _, item := range items {
+		fmt.Printf("- %s\n", item)
+	}
+}
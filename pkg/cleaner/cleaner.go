@@ -1,65 +1,84 @@
 package cleaner
 
 import (
+	"context"
 	"fmt"
 	"os"
-	"path/filepath"
 	"strings"
-)
-
-// CriticalPaths lists critical system paths that should never be touched
-var criticalPaths = []string{
-	"/System",
-	"/bin",
-	"/sbin",
-	"/usr/bin",
-	"/usr/sbin",
-	"/usr/local/bin",
-	"/usr/local/sbin",
-	"/etc",
-	"/var",
-}
 
-// SafeDirs are directories that are safe to remove app-related files from
-var safeDirs = []string{
-	"Library/Application Support",
-	"Library/Preferences",
-	"Library/Caches",
-	"Library/Logs",
-	"Library/Containers",
-	"Library/Saved Application State",
-}
-
-// UnsafeDirs are directories that we should never remove files from
-var unsafeDirs = []string{
-	"Documents",
-	"Downloads",
-	"Desktop",
-	"Pictures",
-	"Music",
-	"Movies",
-}
+	"github.com/alexintosh/gocleaner/pkg/events"
+	"github.com/alexintosh/gocleaner/pkg/finder"
+	"github.com/alexintosh/gocleaner/pkg/paths"
+	"github.com/alexintosh/gocleaner/pkg/policy"
+)
 
 type AppCleaner struct {
-	verbose bool
+	verbose      bool
+	onEvent      events.Handler
+	privileged   PrivilegedDeleter
+	owner        int
+	allowForeign bool
+
+	// remove deletes a checked path and everything inside it
+	remove func(path string) error
 }
 
-// NewAppCleaner creates a new AppCleaner instance
+// NewAppCleaner creates a new AppCleaner instance. Items owned by anyone but
+// the user whose home is cleaned and root are skipped.
 func NewAppCleaner(verbose bool) *AppCleaner {
 	return &AppCleaner{
 		verbose: verbose,
+		owner:   paths.OwnerUID(),
+		remove:  os.RemoveAll,
 	}
 }
 
-// DeleteFiles safely deletes the list of provided files
+// SetEventHandler registers a handler that receives a deleted, skipped or failed
+// event for every file passed to DeleteFiles
+func (c *AppCleaner) SetEventHandler(h events.Handler) {
+	c.onEvent = h
+}
+
+// DeleteFiles safely deletes the list of provided files. With a privileged
+// deleter set, files that need root are deleted by it after all others.
 func (c *AppCleaner) DeleteFiles(files []string) (int, error) {
+	return c.DeleteFilesContext(context.Background(), files)
+}
+
+// DeleteFilesContext is DeleteFiles stopping before the next file once ctx
+// is cancelled. It returns the number deleted so far along with ctx.Err().
+// Files handed to the privileged deleter are deleted as one batch, which is
+// not interrupted once started.
+func (c *AppCleaner) DeleteFilesContext(ctx context.Context, files []string) (int, error) {
 	deleted := 0
+	privileged := []string{}
 	
 	for _, file := range files {
-		if !c.IsSafeToDelete(file) {
+		if err := ctx.Err(); err != nil {
+			return deleted, err
+		}
+		
+		if verdict := c.Verdict(file); !verdict.Allowed {
 			if c.verbose {
-				fmt.Printf("Skipping potentially unsafe path: %s\n", file)
+				fmt.Printf("Skipping potentially unsafe path: %s (%s)\n", file, verdict.Reason)
+			}
+			c.onEvent.Emit(events.Event{Type: events.ItemSkipped, Path: file, Reason: verdict.Reason})
+			continue
+		}
+		
+		if !c.allowForeign {
+			if uid, foreign := ForeignOwner(file, c.owner); foreign {
+				reason := foreignReason(uid)
+				if c.verbose {
+					fmt.Printf("Skipping %s: %s\n", file, reason)
+				}
+				c.onEvent.Emit(events.Event{Type: events.ItemSkipped, Path: file, Reason: reason, ForeignOwner: true})
+				continue
 			}
+		}
+		
+		if c.privileged != nil && NeedsPrivilege(file) {
+			privileged = append(privileged, file)
 			continue
 		}
 		
@@ -67,57 +86,54 @@ func (c *AppCleaner) DeleteFiles(files []string) (int, error) {
 			fmt.Printf("Deleting: %s\n", file)
 		}
 		
-		if err := os.RemoveAll(file); err != nil {
-			fmt.Printf("Error deleting %s: %v\n", file, err)
+		if err := c.remove(file); err != nil {
+			// With a handler attached the error is reported as an event so
+			// that nothing but events ends up on the handler's stream
+			if c.onEvent == nil {
+				fmt.Printf("Error deleting %s: %v\n", file, err)
+			}
+			c.onEvent.Emit(events.Event{Type: events.ItemFailed, Path: file, Error: err.Error()})
 		} else {
 			deleted++
+			c.onEvent.Emit(events.Event{Type: events.ItemDeleted, Path: file})
 		}
 	}
 	
-	return deleted, nil
-}
-
-// DeleteSingleFile deletes a single file and returns any error
-func (c *AppCleaner) DeleteSingleFile(file string) error {
-	if c.verbose {
-		fmt.Printf("Deleting: %s\n", file)
-	}
-	
-	return os.RemoveAll(file)
-}
-
-// IsSafeToDelete checks if a file or directory is safe to delete
-func (c *AppCleaner) IsSafeToDelete(path string) bool {
-	// Check if the path is a critical system path
-	for _, criticalPath := range criticalPaths {
-		if strings.HasPrefix(path, criticalPath) {
-			return false
+	if len(privileged) > 0 {
+		if err := ctx.Err(); err != nil {
+			return deleted, err
 		}
-	}
-	
-	// Check if the path is in an unsafe directory
-	homeDir := os.Getenv("HOME")
-	for _, unsafeDir := range unsafeDirs {
-		unsafePath := filepath.Join(homeDir, unsafeDir)
-		if strings.HasPrefix(path, unsafePath) {
-			return false
+		if c.verbose {
+			fmt.Printf("Deleting %d items that need administrator rights\n", len(privileged))
 		}
-	}
-	
-	// Check if the path is in a safe directory
-	inSafeDir := false
-	for _, safeDir := range safeDirs {
-		safePath := filepath.Join(homeDir, safeDir)
-		if strings.HasPrefix(path, safePath) {
-			inSafeDir = true
-			break
+		handler := c.onEvent
+		if handler == nil {
+			handler = func(e events.Event) {
+				if e.Type == events.ItemFailed {
+					fmt.Printf("Error deleting %s: %s\n", e.Path, e.Error)
+				}
+			}
+		}
+		n, err := c.privileged(privileged, handler)
+		deleted += n
+		if err != nil {
+			return deleted, err
 		}
 	}
 	
-	// Applications directories are also safe
-	if strings.HasPrefix(path, "/Applications/") || strings.HasPrefix(path, filepath.Join(homeDir, "Applications/")) {
-		inSafeDir = true
+	return deleted, nil
+}
+
+// Verdict asks the policy whether a path may be deleted and why. App bundles
+// are evaluated with the bundle ID from their Info.plist.
+func (c *AppCleaner) Verdict(path string) policy.Verdict {
+	bundleIDs := []string{}
+	if strings.HasSuffix(path, ".app") {
+		if info, err := finder.ReadInfoPlist(path); err == nil {
+			if bundleID, ok := info["CFBundleIdentifier"].(string); ok {
+				bundleIDs = append(bundleIDs, bundleID)
+			}
+		}
 	}
-	
-	return inSafeDir
-} 
\ No newline at end of file
+	return policy.Evaluate(policy.SubjectFor(path, bundleIDs...))
+}
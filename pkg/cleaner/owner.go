@@ -0,0 +1,77 @@
+package cleaner
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"os/user"
+	"path/filepath"
+	"strconv"
+	"syscall"
+)
+
+// maxOwnershipChecks bounds how many entries inside a directory are checked
+// for their owner, so huge folders do not stall the deletion
+const maxOwnershipChecks = 1000
+
+// errStopWalk ends a directory walk early
+var errStopWalk = errors.New("stop walk")
+
+// SetAllowForeignOwner allows deleting items owned by other users
+func (c *AppCleaner) SetAllowForeignOwner(allow bool) {
+	c.allowForeign = allow
+}
+
+// ForeignOwner returns the uid of the first account other than owner and
+// root that owns the path or, for directories, one of the first entries
+// inside it. It returns false when no such account was found.
+func ForeignOwner(path string, owner int) (int, bool) {
+	info, err := os.Lstat(path)
+	if err != nil {
+		return 0, false
+	}
+	if uid, ok := foreignUID(info, owner); ok {
+		return uid, true
+	}
+	if !info.IsDir() {
+		return 0, false
+	}
+
+	foreign, found, checked := 0, false, 0
+	filepath.WalkDir(path, func(p string, d fs.DirEntry, err error) error {
+		if err != nil || p == path {
+			return nil
+		}
+		checked++
+		if checked > maxOwnershipChecks {
+			return errStopWalk
+		}
+		if info, err := d.Info(); err == nil {
+			if uid, ok := foreignUID(info, owner); ok {
+				foreign, found = uid, true
+				return errStopWalk
+			}
+		}
+		return nil
+	})
+	return foreign, found
+}
+
+// foreignUID returns the owner of a file when it is neither owner nor root
+func foreignUID(info fs.FileInfo, owner int) (int, bool) {
+	stat, ok := info.Sys().(*syscall.Stat_t)
+	if !ok {
+		return 0, false
+	}
+	uid := int(stat.Uid)
+	return uid, uid != owner && uid != 0
+}
+
+// foreignReason explains which account owns a foreign item
+func foreignReason(uid int) string {
+	if u, err := user.LookupId(strconv.Itoa(uid)); err == nil {
+		return fmt.Sprintf("owned by another user (%s, uid %d)", u.Username, uid)
+	}
+	return fmt.Sprintf("owned by another user (uid %d)", uid)
+}
@@ -0,0 +1,308 @@
+package cleaner
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"syscall"
+
+	"github.com/alexintosh/gocleaner/pkg/events"
+)
+
+// PrivilegedDeleter deletes paths that need root, reports the outcome of each
+// one to handler and returns how many were deleted. It returns an error when
+// the deletion could not be attempted at all.
+type PrivilegedDeleter func(paths []string, handler events.Handler) (int, error)
+
+// Limits of the privileged delete protocol
+const (
+	maxPrivilegedPaths = 10000
+	maxRequestLine     = 4096
+)
+
+// accessWrite is the W_OK mode of access(2)
+const accessWrite = 0x2
+
+// privilegedRequest is one line of the privileged delete protocol. The
+// request is a JSON object per line on stdin, each naming one path.
+type privilegedRequest struct {
+	Path string `json:"path"`
+}
+
+// NeedsPrivilege reports whether deleting a path requires root, either
+// because root owns it or because its parent directory is not writable
+func NeedsPrivilege(path string) bool {
+	if os.Geteuid() == 0 {
+		return false
+	}
+
+	info, err := os.Lstat(path)
+	if err != nil {
+		return false
+	}
+	if stat, ok := info.Sys().(*syscall.Stat_t); ok && stat.Uid == 0 {
+		return true
+	}
+	return syscall.Access(filepath.Dir(path), accessWrite) != nil
+}
+
+// SetPrivilegedDeleter hands every path that needs root to d instead of
+// deleting it directly
+func (c *AppCleaner) SetPrivilegedDeleter(d PrivilegedDeleter) {
+	c.privileged = d
+}
+
+// WritePrivilegedRequest writes the request for deleting paths
+func WritePrivilegedRequest(w io.Writer, paths []string) error {
+	enc := json.NewEncoder(w)
+	for _, p := range paths {
+		if err := enc.Encode(privilegedRequest{Path: p}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ReadPrivilegedRequest reads and validates a request. Every path must be
+// absolute, clean and listed once; anything else rejects the whole request.
+func ReadPrivilegedRequest(r io.Reader) ([]string, error) {
+	paths := []string{}
+	seen := map[string]bool{}
+
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, maxRequestLine), maxRequestLine)
+	line := 0
+	for scanner.Scan() {
+		line++
+		if len(paths) == maxPrivilegedPaths {
+			return nil, fmt.Errorf("request has more than %d paths", maxPrivilegedPaths)
+		}
+
+		var req privilegedRequest
+		dec := json.NewDecoder(bytes.NewReader(scanner.Bytes()))
+		dec.DisallowUnknownFields()
+		if err := dec.Decode(&req); err != nil {
+			return nil, fmt.Errorf("line %d: invalid request: %w", line, err)
+		}
+		if dec.More() {
+			return nil, fmt.Errorf("line %d: invalid request: trailing data", line)
+		}
+
+		p := req.Path
+		switch {
+		case p == "" || strings.ContainsRune(p, 0):
+			return nil, fmt.Errorf("line %d: invalid path %q", line, p)
+		case !filepath.IsAbs(p) || filepath.Clean(p) != p:
+			return nil, fmt.Errorf("line %d: path %q is not absolute and clean", line, p)
+		case p == "/":
+			return nil, fmt.Errorf("line %d: refusing to delete /", line)
+		case seen[p]:
+			return nil, fmt.Errorf("line %d: path %q listed twice", line, p)
+		}
+		seen[p] = true
+		paths = append(paths, p)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("invalid request: %w", err)
+	}
+	if len(paths) == 0 {
+		return nil, errors.New("request lists no paths")
+	}
+	return paths, nil
+}
+
+// ServePrivileged is the root side of the protocol. It reads a request from
+// r, checks every path against the policy again and deletes the allowed
+// ones, writing an NDJSON event per path to w.
+func (c *AppCleaner) ServePrivileged(r io.Reader, w io.Writer) error {
+	paths, err := ReadPrivilegedRequest(r)
+	if err != nil {
+		return err
+	}
+
+	c.verbose = false
+	c.privileged = nil
+	c.remove = removeBeneath
+	c.SetEventHandler(events.NewNDJSONHandler(w))
+
+	// The policy judges the path as written, so a path that leads somewhere
+	// else through a symbolic link is refused before it is judged
+	checked := []string{}
+	for _, p := range paths {
+		if err := checkRequestPath(p); err != nil {
+			c.onEvent.Emit(events.Event{Type: events.ItemSkipped, Path: p, Reason: err.Error()})
+			continue
+		}
+		checked = append(checked, p)
+	}
+	_, err = c.DeleteFiles(checked)
+	return err
+}
+
+// checkRequestPath makes sure a requested path names the item it appears to:
+// its parent folders must not go through symbolic links and the item itself
+// must exist. The item may be a link, which is deleted rather than followed.
+func checkRequestPath(p string) error {
+	parent, err := filepath.EvalSymlinks(filepath.Dir(p))
+	if err != nil {
+		return fmt.Errorf("cannot resolve the parent folder: %w", err)
+	}
+	if resolved := filepath.Join(parent, filepath.Base(p)); resolved != p {
+		return fmt.Errorf("path goes through a symbolic link to %s", resolved)
+	}
+	if _, err := os.Lstat(p); err != nil {
+		return err
+	}
+	return nil
+}
+
+// removeBeneath deletes a checked path through a handle on its parent folder,
+// so that a symbolic link swapped into the path after the check cannot
+// redirect the deletion elsewhere
+func removeBeneath(p string) error {
+	parent := filepath.Dir(p)
+	root, err := os.OpenRoot(parent)
+	if err != nil {
+		return err
+	}
+	defer root.Close()
+
+	// The folder opened must be the one the path names once it is open
+	if err := checkRequestPath(p); err != nil {
+		return err
+	}
+	opened, err := root.Stat(".")
+	if err != nil {
+		return err
+	}
+	named, err := os.Lstat(parent)
+	if err != nil {
+		return err
+	}
+	if !os.SameFile(opened, named) {
+		return fmt.Errorf("%s changed while it was being deleted", parent)
+	}
+	return removeAllIn(root, filepath.Base(p))
+}
+
+// removeAllIn deletes name and everything inside it without leaving root.
+// Symbolic links are deleted, never followed.
+func removeAllIn(root *os.Root, name string) error {
+	info, err := root.Lstat(name)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	if info.IsDir() {
+		dir, err := root.OpenRoot(name)
+		if err != nil {
+			return err
+		}
+		defer dir.Close()
+
+		f, err := dir.Open(".")
+		if err != nil {
+			return err
+		}
+		entries, err := f.ReadDir(-1)
+		f.Close()
+		if err != nil {
+			return err
+		}
+		for _, entry := range entries {
+			if err := removeAllIn(dir, entry.Name()); err != nil {
+				return err
+			}
+		}
+	}
+	return root.Remove(name)
+}
+
+// SudoDeleter returns a deleter that runs argv, a nuke command line serving
+// the protocol, through sudo. Unless interactive, sudo must not prompt for a
+// password and fails when no cached credentials are available.
+func SudoDeleter(argv []string, interactive bool) PrivilegedDeleter {
+	return func(paths []string, handler events.Handler) (int, error) {
+		args := []string{}
+		if !interactive {
+			args = append(args, "-n")
+		}
+		args = append(args, "--")
+		args = append(args, argv...)
+
+		var request, stderr bytes.Buffer
+		if err := WritePrivilegedRequest(&request, paths); err != nil {
+			return 0, err
+		}
+
+		cmd := exec.Command("sudo", args...)
+		cmd.Stdin = &request
+		cmd.Stderr = &stderr
+		stdout, err := cmd.StdoutPipe()
+		if err != nil {
+			return 0, err
+		}
+		if err := cmd.Start(); err != nil {
+			for _, p := range paths {
+				handler.Emit(events.Event{Type: events.ItemFailed, Path: p, Error: fmt.Sprintf("could not run sudo: %v", err)})
+			}
+			return 0, fmt.Errorf("could not run sudo: %w", err)
+		}
+
+		// Only outcomes for the requested paths are taken from the helper
+		pending := map[string]bool{}
+		for _, p := range paths {
+			pending[p] = true
+		}
+		deleted := 0
+		dec := json.NewDecoder(stdout)
+		for {
+			var e events.Event
+			if err := dec.Decode(&e); err != nil {
+				break
+			}
+			if !pending[e.Path] {
+				continue
+			}
+			switch e.Type {
+			case events.ItemDeleted:
+				deleted++
+			case events.ItemSkipped, events.ItemFailed:
+			default:
+				continue
+			}
+			delete(pending, e.Path)
+			handler.Emit(e)
+		}
+		io.Copy(io.Discard, stdout)
+
+		// Whatever the helper did not report on is failed with its reason
+		if err := cmd.Wait(); err != nil || len(pending) > 0 {
+			reason := "privileged helper did not report on this path"
+			if msg := strings.TrimSpace(stderr.String()); msg != "" {
+				// The last line is the one saying why sudo or the helper gave up
+				lines := strings.Split(msg, "\n")
+				reason = lines[len(lines)-1]
+			} else if err != nil {
+				reason = fmt.Sprintf("privileged helper failed: %v", err)
+			}
+			for _, p := range paths {
+				if pending[p] {
+					handler.Emit(events.Event{Type: events.ItemFailed, Path: p, Error: reason})
+				}
+			}
+		}
+		return deleted, nil
+	}
+}
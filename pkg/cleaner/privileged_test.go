@@ -0,0 +1,255 @@
+package cleaner
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/alexintosh/gocleaner/pkg/events"
+	"github.com/alexintosh/gocleaner/pkg/paths"
+)
+
+func TestReadPrivilegedRequest(t *testing.T) {
+	var tooMany strings.Builder
+	for i := 0; i <= maxPrivilegedPaths; i++ {
+		tooMany.WriteString(`{"path":"/a/` + strconv.Itoa(i) + `"}` + "\n")
+	}
+
+	tests := []struct {
+		name    string
+		input   string
+		want    []string
+		wantErr string
+	}{
+		{"one path", `{"path":"/Applications/Foo.app"}` + "\n", []string{"/Applications/Foo.app"}, ""},
+		{"several paths", `{"path":"/a"}` + "\n" + `{"path":"/b"}`, []string{"/a", "/b"}, ""},
+		{"relative path", `{"path":"Library/Caches"}`, nil, "not absolute and clean"},
+		{"dot dot", `{"path":"/Applications/../etc"}`, nil, "not absolute and clean"},
+		{"trailing slash", `{"path":"/Applications/"}`, nil, "not absolute and clean"},
+		{"double slash", `{"path":"/Applications//Foo.app"}`, nil, "not absolute and clean"},
+		{"root", `{"path":"/"}`, nil, "refusing to delete /"},
+		{"empty path", `{"path":""}`, nil, "invalid path"},
+		{"NUL byte", `{"path":"/a\u0000b"}`, nil, "invalid path"},
+		{"duplicate", `{"path":"/a"}` + "\n" + `{"path":"/a"}`, nil, "line 2: path \"/a\" listed twice"},
+		{"unknown field", `{"path":"/a","force":true}`, nil, "unknown field"},
+		{"trailing data", `{"path":"/a"} {"path":"/b"}`, nil, "trailing data"},
+		{"not JSON", `/a`, nil, "line 1: invalid request"},
+		{"over-long line", `{"path":"/` + strings.Repeat("a", maxRequestLine) + `"}`, nil, "token too long"},
+		{"too many paths", tooMany.String(), nil, "more than 10000 paths"},
+		{"no paths", "", nil, "lists no paths"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ReadPrivilegedRequest(strings.NewReader(tt.input))
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("error = %v, want one containing %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if strings.Join(got, "\n") != strings.Join(tt.want, "\n") {
+				t.Fatalf("paths = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestWriteReadPrivilegedRequest(t *testing.T) {
+	want := []string{"/Applications/Foo Bar.app", `/Users/alice/Library/Caches/"quoted"`}
+	var buf bytes.Buffer
+	if err := WritePrivilegedRequest(&buf, want); err != nil {
+		t.Fatal(err)
+	}
+	got, err := ReadPrivilegedRequest(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Fatalf("paths = %q, want %q", got, want)
+	}
+}
+
+func TestServePrivilegedRefusesSymlinkedParent(t *testing.T) {
+	// The temporary folder may itself sit behind a link, e.g. /var on macOS
+	root, err := filepath.EvalSymlinks(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	outside, err := filepath.EvalSymlinks(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := paths.SetRoot(root); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { paths.SetRoot("/") })
+	t.Setenv("HOME", "/Users/alice")
+
+	caches := filepath.Join(root, "Users", "alice", "Library", "Caches")
+	if err := os.MkdirAll(caches, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	secret := filepath.Join(outside, "secret")
+	if err := os.WriteFile(secret, []byte("keep"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Symlink(outside, filepath.Join(caches, "evil")); err != nil {
+		t.Fatal(err)
+	}
+	kept := filepath.Join(caches, "com.example.App")
+	if err := os.WriteFile(kept, []byte("cache"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	var request, response bytes.Buffer
+	through := filepath.Join(caches, "evil", "secret")
+	if err := WritePrivilegedRequest(&request, []string{through, kept}); err != nil {
+		t.Fatal(err)
+	}
+	if err := NewAppCleaner(false).ServePrivileged(&request, &response); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := os.Stat(secret); err != nil {
+		t.Fatalf("file behind the symbolic link was deleted: %v", err)
+	}
+	if _, err := os.Lstat(kept); !os.IsNotExist(err) {
+		t.Fatalf("allowed cache was not deleted: %v", err)
+	}
+
+	outcomes := map[string]events.Type{}
+	scanner := bufio.NewScanner(&response)
+	for scanner.Scan() {
+		var e events.Event
+		if err := json.Unmarshal(scanner.Bytes(), &e); err != nil {
+			t.Fatal(err)
+		}
+		outcomes[e.Path] = e.Type
+	}
+	if outcomes[through] != events.ItemSkipped {
+		t.Errorf("outcome for %s = %q, want %q", through, outcomes[through], events.ItemSkipped)
+	}
+	if outcomes[kept] != events.ItemDeleted {
+		t.Errorf("outcome for %s = %q, want %q", kept, outcomes[kept], events.ItemDeleted)
+	}
+}
+
+func TestServePrivilegedDeletesLinkNotTarget(t *testing.T) {
+	root, err := filepath.EvalSymlinks(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	outside, err := filepath.EvalSymlinks(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := paths.SetRoot(root); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { paths.SetRoot("/") })
+	t.Setenv("HOME", "/Users/alice")
+
+	caches := filepath.Join(root, "Users", "alice", "Library", "Caches")
+	if err := os.MkdirAll(caches, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	secret := filepath.Join(outside, "secret")
+	if err := os.WriteFile(secret, []byte("keep"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	link := filepath.Join(caches, "link")
+	if err := os.Symlink(outside, link); err != nil {
+		t.Fatal(err)
+	}
+
+	var request, response bytes.Buffer
+	if err := WritePrivilegedRequest(&request, []string{link}); err != nil {
+		t.Fatal(err)
+	}
+	if err := NewAppCleaner(false).ServePrivileged(&request, &response); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := os.Lstat(link); !os.IsNotExist(err) {
+		t.Fatalf("link was not deleted: %v", err)
+	}
+	if _, err := os.Stat(secret); err != nil {
+		t.Fatalf("link target was deleted: %v", err)
+	}
+}
+
+func TestRemoveBeneath(t *testing.T) {
+	dir, err := filepath.EvalSymlinks(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	outside, err := filepath.EvalSymlinks(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	secret := filepath.Join(outside, "secret")
+	if err := os.WriteFile(secret, []byte("keep"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	item := filepath.Join(dir, "com.example.App")
+	if err := os.MkdirAll(filepath.Join(item, "a", "b"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(item, "a", "b", "cache.db"), []byte("cache"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Symlink(outside, filepath.Join(item, "a", "link")); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := removeBeneath(item); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Lstat(item); !os.IsNotExist(err) {
+		t.Fatalf("item was not deleted: %v", err)
+	}
+	if _, err := os.Stat(secret); err != nil {
+		t.Fatalf("file behind a link inside the item was deleted: %v", err)
+	}
+
+	// A parent reached through a link is refused
+	link := filepath.Join(dir, "evil")
+	if err := os.Symlink(outside, link); err != nil {
+		t.Fatal(err)
+	}
+	if err := removeBeneath(filepath.Join(link, "secret")); err == nil {
+		t.Fatal("deleted through a symbolic link")
+	}
+	if _, err := os.Stat(secret); err != nil {
+		t.Fatalf("file behind the link was deleted: %v", err)
+	}
+}
+
+func TestSudoDeleterReportsStartFailure(t *testing.T) {
+	// Without sudo on the PATH the helper cannot be started
+	t.Setenv("PATH", t.TempDir())
+
+	failed := []string{}
+	handler := func(e events.Event) {
+		if e.Type == events.ItemFailed {
+			failed = append(failed, e.Path)
+		}
+	}
+	deleted, err := SudoDeleter([]string{"nuke"}, false)([]string{"/a", "/b"}, handler)
+	if err == nil || !strings.Contains(err.Error(), "could not run sudo") {
+		t.Fatalf("error = %v, want one saying sudo could not run", err)
+	}
+	if deleted != 0 || len(failed) != 2 {
+		t.Fatalf("deleted %d and failed %q, want 0 deleted and both failed", deleted, failed)
+	}
+}
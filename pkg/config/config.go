@@ -0,0 +1,281 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/alexintosh/gocleaner/pkg/paths"
+	"github.com/alexintosh/gocleaner/pkg/policy"
+)
+
+// Config holds the settings read from a configuration file. Paths are
+// absolute with ~ already expanded.
+type Config struct {
+	// Path is the file the configuration was read from, empty when none was found
+	Path string
+
+	// Defaults maps command line flag names to their default value
+	Defaults map[string]bool
+
+	ScanRoots        []string
+	ApplicationRoots []string
+	Ignore           []string
+
+	// Rules holds the protected paths as deny rules followed by the policy
+	// rules, in the order they were written
+	Rules []policy.Rule
+
+	// Keys maps TUI actions to the keys that trigger them instead of the defaults
+	Keys map[string][]string
+}
+
+// kinds of values a key may hold
+const (
+	kindBool = iota
+	kindString
+	kindStringList
+)
+
+// schema lists the keys allowed in each section
+var schema = map[string]map[string]int{
+	"defaults": {
+		"dry_run": kindBool,
+		"force":   kindBool,
+		"verbose": kindBool,
+		"no_tui":  kindBool,
+	},
+	"scan": {
+		"roots":             kindStringList,
+		"application_roots": kindStringList,
+		"ignore":            kindStringList,
+	},
+	"protect": {
+		"paths": kindStringList,
+	},
+	"policy": {
+		"rules": kindStringList,
+	},
+	"keys": {
+		"up":           kindStringList,
+		"down":         kindStringList,
+		"prev_page":    kindStringList,
+		"next_page":    kindStringList,
+		"expand":       kindStringList,
+		"collapse":     kindStringList,
+		"expand_all":   kindStringList,
+		"collapse_all": kindStringList,
+		"toggle":       kindStringList,
+		"select_all":   kindStringList,
+		"select_none":  kindStringList,
+		"include":      kindStringList,
+		"filter":       kindStringList,
+		"clear_filter": kindStringList,
+		"sort":         kindStringList,
+		"confirm":      kindStringList,
+		"help":         kindStringList,
+		"quit":         kindStringList,
+	},
+}
+
+// DefaultPath returns the location of the configuration file of the user
+// running nuke, which under sudo is the user who ran sudo
+func DefaultPath() string {
+	return filepath.Join(paths.InvokingHome(), ".config", "nuke", "config.toml")
+}
+
+// Load reads and validates the configuration file at path. A missing file
+// yields an empty configuration unless required is set.
+func Load(path string, required bool) (*Config, error) {
+	cfg := &Config{Defaults: map[string]bool{}, Keys: map[string][]string{}}
+
+	content, err := os.ReadFile(path)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) && !required {
+			return cfg, nil
+		}
+		return nil, fmt.Errorf("error reading config: %w", err)
+	}
+	cfg.Path = path
+
+	doc, err := parseTOML(path, string(content))
+	if err != nil {
+		return nil, err
+	}
+	if err := validate(path, doc, schema); err != nil {
+		return nil, err
+	}
+
+	for key, v := range doc["defaults"] {
+		cfg.Defaults[strings.ReplaceAll(key, "_", "-")] = v.v.(bool)
+	}
+
+	lists := []struct {
+		section, key string
+		dest         *[]string
+		paths        bool
+	}{
+		{"scan", "roots", &cfg.ScanRoots, true},
+		{"scan", "application_roots", &cfg.ApplicationRoots, true},
+		{"scan", "ignore", &cfg.Ignore, false},
+	}
+	for _, l := range lists {
+		v, ok := doc[l.section][l.key]
+		if !ok {
+			continue
+		}
+		for i, item := range v.v.([]interface{}) {
+			line := v.items[i]
+			s := expandHome(item.(string))
+			if l.paths && !filepath.IsAbs(s) {
+				return nil, &Error{path, line, fmt.Sprintf("%s.%s: %q must be an absolute path or start with ~/", l.section, l.key, item)}
+			}
+			if !l.paths {
+				if _, err := filepath.Match(s, ""); err != nil {
+					return nil, &Error{path, line, fmt.Sprintf("%s.%s: invalid pattern %q", l.section, l.key, item)}
+				}
+			}
+			if l.paths {
+				s = filepath.Clean(s)
+				if held, ok := holdsProtected(s); ok {
+					return nil, &Error{path, line, fmt.Sprintf("%s.%s: %q contains %s; roots may not hold system locations or the home folder", l.section, l.key, item, held)}
+				}
+			}
+			*l.dest = append(*l.dest, s)
+		}
+	}
+
+	if v, ok := doc["protect"]["paths"]; ok {
+		for i, item := range v.v.([]interface{}) {
+			line := v.items[i]
+			p := expandHome(item.(string))
+			if !filepath.IsAbs(p) {
+				return nil, &Error{path, line, fmt.Sprintf("protect.paths: %q must be an absolute path or start with ~/", item)}
+			}
+			cfg.Rules = append(cfg.Rules, policy.Rule{Action: policy.Deny, Pattern: filepath.Clean(p), Source: fmt.Sprintf("%s:%d", path, line)})
+		}
+	}
+
+	if v, ok := doc["policy"]["rules"]; ok {
+		for i, item := range v.v.([]interface{}) {
+			line := v.items[i]
+			rule, err := policy.ParseRule(item.(string), fmt.Sprintf("%s:%d", path, line))
+			if err != nil {
+				return nil, &Error{path, line, fmt.Sprintf("policy.rules: %v", err)}
+			}
+			cfg.Rules = append(cfg.Rules, rule)
+		}
+	}
+
+	for action, v := range doc["keys"] {
+		keys := []string{}
+		for _, item := range v.v.([]interface{}) {
+			keys = append(keys, item.(string))
+		}
+		if len(keys) == 0 {
+			return nil, &Error{path, v.line, fmt.Sprintf("keys.%s: at least one key is needed", action)}
+		}
+		cfg.Keys[action] = keys
+	}
+
+	return cfg, nil
+}
+
+// validate checks every section and key against the schema and reports all
+// problems in the order they appear in the file
+func validate(path string, doc document, schema map[string]map[string]int) error {
+	problems := Errors{}
+
+	for section, keys := range doc {
+		allowed, ok := schema[section]
+		for key, v := range keys {
+			switch {
+			case section == "":
+				problems = append(problems, &Error{path, v.line, fmt.Sprintf("key %q must be inside a section (%s)", key, sectionNames(schema))})
+			case !ok:
+				problems = append(problems, &Error{path, v.line, fmt.Sprintf("key %q is in unknown section [%s] (expected %s)", key, section, sectionNames(schema))})
+			default:
+				kind, known := allowed[key]
+				if !known {
+					problems = append(problems, &Error{path, v.line, fmt.Sprintf("unknown key %q in [%s]", key, section)})
+				} else if line, err := checkKind(kind, v); err != nil {
+					problems = append(problems, &Error{path, line, fmt.Sprintf("%s.%s %s", section, key, err)})
+				}
+			}
+		}
+	}
+
+	if len(problems) == 0 {
+		return nil
+	}
+	sort.Slice(problems, func(i, j int) bool { return problems[i].Line < problems[j].Line })
+	return problems
+}
+
+// checkKind verifies a value has the kind the schema expects and returns the
+// line of the offending item along with the problem
+func checkKind(kind int, v value) (int, error) {
+	switch kind {
+	case kindBool:
+		if _, ok := v.v.(bool); !ok {
+			return v.line, fmt.Errorf("must be true or false")
+		}
+	case kindString:
+		if _, ok := v.v.(string); !ok {
+			return v.line, fmt.Errorf("must be a string")
+		}
+	case kindStringList:
+		items, ok := v.v.([]interface{})
+		if !ok {
+			return v.line, fmt.Errorf("must be an array of strings")
+		}
+		for i, item := range items {
+			if _, ok := item.(string); !ok {
+				return v.items[i], fmt.Errorf("must be an array of strings")
+			}
+		}
+	}
+	return 0, nil
+}
+
+// sectionNames lists the known sections for error messages
+func sectionNames(schema map[string]map[string]int) string {
+	names := []string{}
+	for name := range schema {
+		names = append(names, "["+name+"]")
+	}
+	sort.Strings(names)
+	return strings.Join(names, ", ")
+}
+
+// holdsProtected reports the system location or home folder a root is or
+// contains. Everything the finder matches in a root may be deleted, so such
+// a root would put them within reach.
+func holdsProtected(root string) (string, bool) {
+	protected := []string{paths.Home()}
+	for _, loc := range policy.SystemLocations {
+		protected = append(protected, paths.Rebase(loc))
+	}
+	for _, p := range protected {
+		if p != "" && (root == p || root == "/" || strings.HasPrefix(p, root+"/")) {
+			return p, true
+		}
+	}
+	return "", false
+}
+
+// expandHome replaces a leading ~ with the home directory being cleaned,
+// which follows --root and --user
+func expandHome(path string) string {
+	if path == "~" {
+		return paths.Home()
+	}
+	if strings.HasPrefix(path, "~/") {
+		return filepath.Join(paths.Home(), path[2:])
+	}
+	return path
+}
@@ -0,0 +1,332 @@
+package config
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/BurntSushi/toml"
+)
+
+func TestParseTOML(t *testing.T) {
+	content := `# nuke configuration
+[defaults]
+dry_run = true   # always look first
+verbose = false
+
+[scan]
+roots = [
+  "~/Library/Application Support", # the usual place
+  '/opt/data # not a comment',
+  "/srv/\"quoted\"",
+]
+ignore = ["*.keep", "# not a comment either"]
+
+[limits]
+count = 1_000
+name = 'single # quoted'
+"quoted key" = "tab\there \u00e9"
+`
+	doc, err := parseTOML("config.toml", content)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		section, key string
+		want         interface{}
+		line         int
+		items        []int
+	}{
+		{"defaults", "dry_run", true, 3, nil},
+		{"defaults", "verbose", false, 4, nil},
+		{"scan", "roots", []interface{}{"~/Library/Application Support", "/opt/data # not a comment", `/srv/"quoted"`}, 7, []int{8, 9, 10}},
+		{"scan", "ignore", []interface{}{"*.keep", "# not a comment either"}, 12, []int{12, 12}},
+		{"limits", "count", int64(1000), 15, nil},
+		{"limits", "name", "single # quoted", 16, nil},
+		{"limits", "quoted key", "tab\there \u00e9", 17, nil},
+	}
+	for _, tt := range tests {
+		v, ok := doc[tt.section][tt.key]
+		if !ok {
+			t.Errorf("%s.%s is missing", tt.section, tt.key)
+			continue
+		}
+		if !reflect.DeepEqual(v.v, tt.want) {
+			t.Errorf("%s.%s = %#v, want %#v", tt.section, tt.key, v.v, tt.want)
+		}
+		if v.line != tt.line {
+			t.Errorf("%s.%s is on line %d, want %d", tt.section, tt.key, v.line, tt.line)
+		}
+		if !reflect.DeepEqual(v.items, tt.items) {
+			t.Errorf("items of %s.%s are on lines %v, want %v", tt.section, tt.key, v.items, tt.items)
+		}
+	}
+}
+
+func TestParseTOMLErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		line    int
+		msg     string
+	}{
+		{"duplicate key", "[scan]\nroots = []\n\nroots = []\n", 4, `key "roots" is defined more than once`},
+		{"duplicate section", "[scan]\nroots = []\n[defaults]\n[scan]\n", 4, "section [scan] is defined more than once"},
+		{"array of tables", "[[scan]]\n", 1, "invalid section header"},
+		{"unclosed header", "[scan\n", 1, "invalid section header"},
+		{"empty section", "[ ]\n", 1, "empty section name"},
+		{"no equals", "[scan]\nroots\n", 2, "expected key = value"},
+		{"no key", "[scan]\n= 1\n", 2, "missing key before ="},
+		{"no value", "[defaults]\ndry_run =\n", 2, "missing value"},
+		{"unquoted string", "[scan]\nname = hello\n", 2, "strings need quotes"},
+		{"unterminated string", "[scan]\nname = \"hello\n", 2, "unterminated string"},
+		{"text after string", "[scan]\nname = \"a\" \"b\"\n", 2, `unexpected "\"b\"" after the value`},
+		{"text after string in array", "[scan]\nroots = [\"a\"b]\n", 2, "after string"},
+		{"text after header", "[scan] roots = []\n", 1, "after the section header"},
+		{"bad escape", "[scan]\nname = \"a\\qb\"\n", 2, "unsupported escape"},
+		{"unterminated array", "[scan]\nroots = [\n  \"a\",\n", 2, "unterminated array"},
+		{"nested array", "[scan]\nroots = [[\"a\"]]\n", 2, "nested arrays are not supported"},
+		{"missing comma", "[scan]\nroots = [\n  \"a\"\n  \"b\"\n]\n", 4, "expected , between array items"},
+		{"dotted key", "[scan]\nroots.extra = []\n", 2, "dotted keys are not supported"},
+		{"dotted section", "[scan.extra]\n", 1, "dotted section names are not supported"},
+		{"quoted section", "[\"scan\"]\n", 1, "section names may only hold letters, digits, _ and -"},
+		{"key and section clash", "scan = 1\n[scan]\n", 2, "has the name of the key on line 1"},
+		{"inline table", "[scan]\nroots = {a = 1}\n", 2, "inline tables are not supported"},
+		{"multi-line string", "[scan]\nname = \"\"\"a\"\"\"\n", 2, "multi-line strings are not supported"},
+		{"leading zero", "[limits]\ncount = 012\n", 2, "is not a string, boolean, integer or array"},
+		{"float", "[limits]\ncount = 1.5\n", 2, "is not a string, boolean, integer or array"},
+		{"big integer", "[limits]\ncount = 99999999999999999999\n", 2, "out of range"},
+		{"bad unicode escape", "[scan]\nname = \"\\uD800\"\n", 2, "invalid escape"},
+		{"control character", "[scan]\n\nname = \"a\x01\"\n", 3, "control character U+0001"},
+		{"invalid UTF-8", "[scan]\nname = \"\xff\"\n", 2, "not valid UTF-8"},
+		{"bad item", "[scan]\nroots = [\n  \"a\",\n  b,\n]\n", 4, "strings need quotes"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := parseTOML("config.toml", tt.content)
+			var cfgErr *Error
+			if !errors.As(err, &cfgErr) {
+				t.Fatalf("error = %v, want a configuration error", err)
+			}
+			if cfgErr.Line != tt.line || !strings.Contains(cfgErr.Msg, tt.msg) {
+				t.Errorf("error = %v, want line %d and a message containing %q", err, tt.line, tt.msg)
+			}
+			if !strings.HasPrefix(err.Error(), "config.toml:") {
+				t.Errorf("error %q does not name the file and line", err)
+			}
+		})
+	}
+}
+
+// FuzzParseTOML checks the parser against a complete TOML implementation:
+// whatever it accepts must decode to the same values there, and whatever it
+// rejects must be reported on a line of the document
+func FuzzParseTOML(f *testing.F) {
+	seeds := []string{
+		"[defaults]\ndry_run = true # look first\n",
+		"[scan]\nroots = [\n  \"~/Library\", # comment\n  '/opt/x',\n]\n",
+		"[keys]\nquit = [\"q\", \"ctrl+c\"]\r\n",
+		"[limits]\ncount = -1_000\n\"quoted key\" = 'x'\n",
+		"[s]\nname = \"tab\\there \\u00e9\\U0001F600\"\n",
+		"[s]\nn = 012\nf = 1.5\nd = 1979-05-27\n",
+		"[a.b]\nx = 1\n",
+		"[s]\na.b = 1\nt = {x = 1}\nm = \"\"\"x\"\"\"\n",
+		"x = 1\n[x]\n",
+	}
+	for _, seed := range seeds {
+		f.Add(seed)
+	}
+
+	f.Fuzz(func(t *testing.T, content string) {
+		doc, err := parseTOML("fuzz.toml", content)
+		want := map[string]interface{}{}
+		_, decodeErr := toml.Decode(content, &want)
+
+		if err != nil {
+			var cfgErr *Error
+			if !errors.As(err, &cfgErr) || cfgErr.Line < 1 || cfgErr.Line > strings.Count(content, "\n")+1 {
+				t.Fatalf("error %v is not tied to a line of the document", err)
+			}
+			return
+		}
+		if decodeErr != nil {
+			t.Fatalf("accepted a document that is not valid TOML: %v", decodeErr)
+		}
+
+		got := map[string]interface{}{}
+		for section, keys := range doc {
+			values := got
+			if section != "" {
+				values = map[string]interface{}{}
+				got[section] = values
+			}
+			for key, v := range keys {
+				values[key] = v.v
+				if items, ok := v.v.([]interface{}); ok && len(v.items) != len(items) {
+					t.Fatalf("%s.%s has %d items but %d lines", section, key, len(items), len(v.items))
+				}
+			}
+		}
+		if !reflect.DeepEqual(got, want) {
+			t.Fatalf("parsed %#v, want %#v", got, want)
+		}
+	})
+}
+
+func TestValidate(t *testing.T) {
+	content := `top = 1
+[defaults]
+dry_run = "yes"
+force = true
+[scan]
+roots = "/opt"
+ignore = ["a", 1]
+colour = ["red"]
+[extras]
+x = 1
+[keys]
+quit = true
+help = [
+  "?",
+  false,
+]
+`
+	doc, err := parseTOML("config.toml", content)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = validate("config.toml", doc, schema)
+	var problems Errors
+	if !errors.As(err, &problems) {
+		t.Fatalf("error = %v, want a list of problems", err)
+	}
+
+	want := []struct {
+		line int
+		msg  string
+	}{
+		{1, `key "top" must be inside a section`},
+		{3, "defaults.dry_run must be true or false"},
+		{6, "scan.roots must be an array of strings"},
+		{7, "scan.ignore must be an array of strings"},
+		{8, `unknown key "colour" in [scan]`},
+		{10, `key "x" is in unknown section [extras]`},
+		{12, "keys.quit must be an array of strings"},
+		{15, "keys.help must be an array of strings"},
+	}
+	if len(problems) != len(want) {
+		t.Fatalf("got %d problems, want %d:\n%v", len(problems), len(want), err)
+	}
+	for i, w := range want {
+		if problems[i].Line != w.line || !strings.Contains(problems[i].Msg, w.msg) {
+			t.Errorf("problem %d = %v, want line %d and a message containing %q", i, problems[i], w.line, w.msg)
+		}
+	}
+}
+
+func TestLoad(t *testing.T) {
+	t.Setenv("HOME", "/Users/alice")
+	path := filepath.Join(t.TempDir(), "config.toml")
+	content := `[defaults]
+dry_run = true
+no_tui = true
+
+[scan]
+roots = ["~/Work/Caches", "/opt/cache/"]
+ignore = ["*.keep"]
+
+[protect]
+paths = ["~/Library/Caches/keep"]
+
+[policy]
+rules = [
+  "deny bundle:com.apple.*",
+]
+
+[keys]
+toggle = ["space", "x"]
+`
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg, err := Load(path, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(cfg.Defaults, map[string]bool{"dry-run": true, "no-tui": true}) {
+		t.Errorf("Defaults = %v", cfg.Defaults)
+	}
+	if !reflect.DeepEqual(cfg.ScanRoots, []string{"/Users/alice/Work/Caches", "/opt/cache"}) {
+		t.Errorf("ScanRoots = %q", cfg.ScanRoots)
+	}
+	if !reflect.DeepEqual(cfg.Ignore, []string{"*.keep"}) {
+		t.Errorf("Ignore = %q", cfg.Ignore)
+	}
+	if len(cfg.Rules) != 2 {
+		t.Fatalf("got %d rules, want 2", len(cfg.Rules))
+	}
+	if r := cfg.Rules[0]; r.Pattern != "/Users/alice/Library/Caches/keep" || r.Source != path+":10" {
+		t.Errorf("protected path rule = %+v", r)
+	}
+	if r := cfg.Rules[1]; r.BundleID != "com.apple.*" || r.Source != path+":14" {
+		t.Errorf("policy rule = %+v", r)
+	}
+	if !reflect.DeepEqual(cfg.Keys, map[string][]string{"toggle": {"space", "x"}}) {
+		t.Errorf("Keys = %v", cfg.Keys)
+	}
+}
+
+func TestLoadErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		msg     string
+	}{
+		{"relative scan root", "[scan]\nroots = [\"Work\"]\n", `config.toml:2: scan.roots: "Work" must be an absolute path`},
+		{"root holding the system", "[scan]\nroots = [\"/\"]\n", `config.toml:2: scan.roots: "/" contains /Users/alice; roots may not hold system locations or the home folder`},
+		{"root holding the homes", "[scan]\nroots = [\n  \"/opt\",\n  \"/Users/\",\n]\n", `config.toml:4: scan.roots: "/Users/" contains /Users/alice`},
+		{"home as root", "[scan]\napplication_roots = [\"~\"]\n", `config.toml:2: scan.application_roots: "~" contains /Users/alice`},
+		{"system location as root", "[scan]\nroots = [\"/private/var\"]\n", `"/private/var" contains /private/var`},
+		{"bad ignore pattern", "[scan]\nignore = [\"[\"]\n", `config.toml:2: scan.ignore: invalid pattern "["`},
+		{"relative protected path", "[protect]\npaths = [\"Documents\"]\n", `config.toml:2: protect.paths: "Documents" must be an absolute path`},
+		{"bad rule", "[policy]\nrules = [\n  \"keep /opt\",\n]\n", "config.toml:3: policy.rules: rule \"keep /opt\" must start with allow or deny"},
+		{"no keys", "[keys]\nquit = []\n", "config.toml:2: keys.quit: at least one key is needed"},
+	}
+
+	t.Setenv("HOME", "/Users/alice")
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "config.toml")
+			if err := os.WriteFile(path, []byte(tt.content), 0o644); err != nil {
+				t.Fatal(err)
+			}
+			_, err := Load(path, true)
+			if err == nil || !strings.Contains(err.Error(), tt.msg) {
+				t.Errorf("error = %v, want one containing %q", err, tt.msg)
+			}
+		})
+	}
+}
+
+func TestLoadMissing(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "missing.toml")
+
+	cfg, err := Load(path, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.Path != "" || len(cfg.Rules) != 0 {
+		t.Errorf("missing optional file gave %+v", cfg)
+	}
+
+	if _, err := Load(path, true); err == nil {
+		t.Error("missing required file was accepted")
+	}
+}
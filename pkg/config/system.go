@@ -0,0 +1,95 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+
+	"github.com/alexintosh/gocleaner/pkg/policy"
+)
+
+// SystemPolicyPaths are the locations of the policy files an administrator
+// can install. Unlike the user's configuration they are always read and
+// cannot be overridden.
+var SystemPolicyPaths = []string{
+	"/etc/nuke/policy.toml",
+	"/Library/Application Support/nuke/policy.toml",
+}
+
+// SystemPolicy holds the rules read from the system policy files
+type SystemPolicy struct {
+	// Paths lists the files that were read
+	Paths []string
+
+	// Rules protects every listed bundle ID and path
+	Rules []policy.Rule
+
+	// Message is shown when an uninstall is refused, e.g. who to contact
+	Message string
+}
+
+// systemSchema lists the keys allowed in a system policy file
+var systemSchema = map[string]map[string]int{
+	"protect": {
+		"bundle_ids": kindStringList,
+		"paths":      kindStringList,
+		"message":    kindString,
+	},
+}
+
+// LoadSystemPolicy reads every system policy file that exists. Any problem
+// with a file that exists is an error, so a broken policy never silently
+// stops protecting anything.
+func LoadSystemPolicy() (*SystemPolicy, error) {
+	sp := &SystemPolicy{}
+
+	for _, path := range SystemPolicyPaths {
+		content, err := os.ReadFile(path)
+		if err != nil {
+			if errors.Is(err, fs.ErrNotExist) {
+				continue
+			}
+			return nil, fmt.Errorf("error reading system policy: %w", err)
+		}
+		sp.Paths = append(sp.Paths, path)
+
+		doc, err := parseTOML(path, string(content))
+		if err != nil {
+			return nil, err
+		}
+		if err := validate(path, doc, systemSchema); err != nil {
+			return nil, err
+		}
+
+		protect := doc["protect"]
+		if v, ok := protect["message"]; ok && sp.Message == "" {
+			sp.Message = v.v.(string)
+		}
+
+		if v, ok := protect["bundle_ids"]; ok {
+			for i, item := range v.v.([]interface{}) {
+				line := v.items[i]
+				rule, err := policy.ParseRule("deny bundle:"+item.(string), fmt.Sprintf("system policy %s:%d", path, line))
+				if err != nil {
+					return nil, &Error{path, line, fmt.Sprintf("protect.bundle_ids: invalid bundle ID pattern %q", item)}
+				}
+				sp.Rules = append(sp.Rules, rule)
+			}
+		}
+
+		if v, ok := protect["paths"]; ok {
+			for i, item := range v.v.([]interface{}) {
+				line := v.items[i]
+				p := item.(string)
+				if !filepath.IsAbs(p) {
+					return nil, &Error{path, line, fmt.Sprintf("protect.paths: %q must be an absolute path", p)}
+				}
+				sp.Rules = append(sp.Rules, policy.Rule{Action: policy.Deny, Pattern: filepath.Clean(p), Source: fmt.Sprintf("system policy %s:%d", path, line)})
+			}
+		}
+	}
+
+	return sp, nil
+}
@@ -0,0 +1,485 @@
+package config
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// value is a parsed TOML value and the line it was defined on
+type value struct {
+	v    interface{}
+	line int
+
+	// items holds the line of every item when the value is an array
+	items []int
+}
+
+// document maps section names to their keys. Keys outside of any section
+// live in the "" section.
+type document map[string]map[string]value
+
+// Error is a configuration problem tied to a line of the file
+type Error struct {
+	File string
+	Line int
+	Msg  string
+}
+
+func (e *Error) Error() string {
+	if e.Line == 0 {
+		return fmt.Sprintf("%s: %s", e.File, e.Msg)
+	}
+	return fmt.Sprintf("%s:%d: %s", e.File, e.Line, e.Msg)
+}
+
+// Errors is a list of configuration problems
+type Errors []*Error
+
+func (e Errors) Error() string {
+	lines := make([]string, 0, len(e))
+	for _, err := range e {
+		lines = append(lines, err.Error())
+	}
+	return strings.Join(lines, "\n")
+}
+
+// parseTOML parses the subset of TOML used by nuke's configuration files.
+// Every document it accepts is valid TOML with the same meaning; anything
+// else is rejected with the line at fault:
+//
+//   - [section] headers whose name is a bare key, neither dotted nor an
+//     array of tables
+//   - key = value pairs, where the key is bare or quoted but not dotted
+//   - single-line basic and literal strings, booleans, decimal integers and
+//     arrays of those, which may span several lines
+//
+// Multi-line strings, floats, dates, inline tables and nested arrays are
+// not supported.
+func parseTOML(file, content string) (document, error) {
+	content, err := checkText(file, content)
+	if err != nil {
+		return nil, err
+	}
+
+	p := &parser{file: file, src: content, line: 1}
+	doc := document{"": {}}
+	section := ""
+
+	for {
+		p.skipSpace()
+		if p.skipComment() {
+			continue
+		}
+		if p.eof() {
+			return doc, nil
+		}
+		if p.skipNewline() {
+			continue
+		}
+
+		lineNo := p.line
+		if p.peek() == '[' {
+			name, err := p.sectionHeader()
+			if err != nil {
+				return nil, err
+			}
+			if _, ok := doc[name]; ok {
+				return nil, p.errorf(lineNo, "section [%s] is defined more than once", name)
+			}
+			if v, ok := doc[""][name]; ok {
+				return nil, p.errorf(lineNo, "section [%s] has the name of the key on line %d", name, v.line)
+			}
+			doc[name] = map[string]value{}
+			section = name
+			if err := p.endOfLine("the section header"); err != nil {
+				return nil, err
+			}
+			continue
+		}
+
+		key, err := p.key()
+		if err != nil {
+			return nil, err
+		}
+		if _, ok := doc[section][key]; ok {
+			return nil, p.errorf(lineNo, "key %q is defined more than once", key)
+		}
+		v, err := p.value(key)
+		if err != nil {
+			return nil, err
+		}
+		doc[section][key] = v
+		if err := p.endOfLine("the value"); err != nil {
+			return nil, err
+		}
+	}
+}
+
+// parser reads a document one byte at a time and keeps track of the line
+type parser struct {
+	file string
+	src  string
+	pos  int
+	line int
+}
+
+func (p *parser) eof() bool {
+	return p.pos >= len(p.src)
+}
+
+// peek returns the current byte, or 0 at the end of the document
+func (p *parser) peek() byte {
+	if p.eof() {
+		return 0
+	}
+	return p.src[p.pos]
+}
+
+// rest returns what is left of the current line, for error messages
+func (p *parser) rest() string {
+	line, _, _ := strings.Cut(p.src[p.pos:], "\n")
+	return strings.TrimSpace(stripComment(line))
+}
+
+func (p *parser) errorf(line int, format string, args ...interface{}) *Error {
+	return &Error{p.file, line, fmt.Sprintf(format, args...)}
+}
+
+// skipSpace skips spaces and tabs
+func (p *parser) skipSpace() {
+	for p.peek() == ' ' || p.peek() == '\t' {
+		p.pos++
+	}
+}
+
+// skipComment skips a # comment up to the end of the line
+func (p *parser) skipComment() bool {
+	if p.peek() != '#' {
+		return false
+	}
+	for !p.eof() && p.peek() != '\n' {
+		p.pos++
+	}
+	return true
+}
+
+// skipNewline skips a line break and counts it
+func (p *parser) skipNewline() bool {
+	if p.peek() != '\n' {
+		return false
+	}
+	p.pos++
+	p.line++
+	return true
+}
+
+// endOfLine checks that nothing but a comment follows what was just parsed
+func (p *parser) endOfLine(what string) error {
+	p.skipSpace()
+	p.skipComment()
+	if !p.eof() && !p.skipNewline() {
+		return p.errorf(p.line, "unexpected %q after %s", p.rest(), what)
+	}
+	return nil
+}
+
+// sectionHeader parses a [section] header
+func (p *parser) sectionHeader() (string, error) {
+	lineNo := p.line
+	header := p.rest()
+	invalid := func(why string) error {
+		return p.errorf(lineNo, "invalid section header %q: %s", header, why)
+	}
+
+	p.pos++
+	p.skipSpace()
+	if p.peek() == '[' {
+		return "", invalid("arrays of tables are not supported")
+	}
+	name := p.bareKey()
+	p.skipSpace()
+	switch p.peek() {
+	case ']':
+		p.pos++
+	case '.':
+		return "", invalid("dotted section names are not supported")
+	case '\n', 0:
+		return "", invalid("missing ]")
+	default:
+		return "", invalid("section names may only hold letters, digits, _ and -")
+	}
+
+	if name == "" {
+		return "", p.errorf(lineNo, "empty section name")
+	}
+	return name, nil
+}
+
+// key parses the key of a key = value pair up to and including the =
+func (p *parser) key() (string, error) {
+	lineNo := p.line
+	line := p.rest()
+
+	var key string
+	switch p.peek() {
+	case '=':
+		return "", p.errorf(lineNo, "missing key before =")
+	case '"', '\'':
+		s, n, err := parseString(p.src[p.pos:])
+		if err != nil {
+			return "", p.errorf(lineNo, "invalid key: %v", err)
+		}
+		key = s
+		p.pos += n
+	default:
+		key = p.bareKey()
+		if key == "" {
+			return "", p.errorf(lineNo, "expected key = value, got %q", line)
+		}
+	}
+
+	p.skipSpace()
+	switch p.peek() {
+	case '=':
+		p.pos++
+		return key, nil
+	case '.':
+		return "", p.errorf(lineNo, "dotted keys are not supported: %q", line)
+	}
+	return "", p.errorf(lineNo, "expected key = value, got %q", line)
+}
+
+// bareKey reads a key made of letters, digits, _ and -
+func (p *parser) bareKey() string {
+	start := p.pos
+	for c := p.peek(); c == '_' || c == '-' || '0' <= c && c <= '9' || 'a' <= c && c <= 'z' || 'A' <= c && c <= 'Z'; c = p.peek() {
+		p.pos++
+	}
+	return p.src[start:p.pos]
+}
+
+// value parses the value of key, which starts at the current position
+func (p *parser) value(key string) (value, error) {
+	lineNo := p.line
+	p.skipSpace()
+	if p.peek() == '[' {
+		return p.array(key)
+	}
+
+	v, err := p.scalar()
+	if err != nil {
+		return value{}, p.errorf(lineNo, "invalid value for %q: %v", key, err)
+	}
+	return value{v: v, line: lineNo}, nil
+}
+
+// array parses an array whose items may be spread over several lines
+func (p *parser) array(key string) (value, error) {
+	lineNo := p.line
+	p.pos++
+	items := []interface{}{}
+	lines := []int{}
+
+	for {
+		p.skipBlank()
+		switch p.peek() {
+		case 0:
+			return value{}, p.errorf(lineNo, "invalid value for %q: unterminated array", key)
+		case ']':
+			p.pos++
+			return value{v: items, line: lineNo, items: lines}, nil
+		case '[':
+			return value{}, p.errorf(p.line, "invalid value for %q: nested arrays are not supported", key)
+		}
+
+		itemLine := p.line
+		item, err := p.scalar()
+		if err != nil {
+			return value{}, p.errorf(itemLine, "invalid value for %q: %v", key, err)
+		}
+		items = append(items, item)
+		lines = append(lines, itemLine)
+
+		p.skipBlank()
+		switch p.peek() {
+		case ',':
+			p.pos++
+		case ']':
+		case 0:
+			return value{}, p.errorf(lineNo, "invalid value for %q: unterminated array", key)
+		default:
+			return value{}, p.errorf(p.line, "invalid value for %q: expected , between array items, got %q", key, p.rest())
+		}
+	}
+}
+
+// skipBlank skips whitespace, line breaks and comments inside an array
+func (p *parser) skipBlank() {
+	for {
+		p.skipSpace()
+		if !p.skipComment() && !p.skipNewline() {
+			return
+		}
+	}
+}
+
+// scalar parses a string, boolean or integer
+func (p *parser) scalar() (interface{}, error) {
+	switch p.peek() {
+	case '"', '\'':
+		s, n, err := parseString(p.src[p.pos:])
+		if err != nil {
+			return nil, err
+		}
+		p.pos += n
+		return s, p.afterString()
+	case '{':
+		return nil, fmt.Errorf("inline tables are not supported")
+	}
+
+	start := p.pos
+	for !p.eof() && !strings.ContainsRune(" \t\n#,]", rune(p.peek())) {
+		p.pos++
+	}
+	raw := p.src[start:p.pos]
+	switch raw {
+	case "":
+		return nil, fmt.Errorf("missing value")
+	case "true":
+		return true, nil
+	case "false":
+		return false, nil
+	}
+	if integerPattern.MatchString(raw) {
+		n, err := strconv.ParseInt(strings.ReplaceAll(raw, "_", ""), 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("integer %s is out of range", raw)
+		}
+		return n, nil
+	}
+	return nil, fmt.Errorf("%q is not a string, boolean, integer or array (strings need quotes)", raw)
+}
+
+// integerPattern matches decimal integers, where an underscore may separate
+// digits and leading zeros are not allowed
+var integerPattern = regexp.MustCompile(`^[+-]?(0|[1-9](_?[0-9])*)$`)
+
+// afterString checks that a string is not directly followed by more text
+func (p *parser) afterString() error {
+	if c := p.peek(); c != 0 && !strings.ContainsRune(" \t\n#,]", rune(c)) {
+		return fmt.Errorf("unexpected %q after string", p.rest())
+	}
+	return nil
+}
+
+// stripComment removes a # comment that is not inside a string
+func stripComment(line string) string {
+	if i := scanOutsideStrings(line, func(c byte) bool { return c == '#' }); i >= 0 {
+		return line[:i]
+	}
+	return line
+}
+
+// scanOutsideStrings calls stop for every byte that is not part of a quoted
+// string and returns the index of the first byte it returned true for, or -1
+func scanOutsideStrings(s string, stop func(c byte) bool) int {
+	var quote byte
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case quote == '"' && c == '\\':
+			i++
+		case quote != 0:
+			if c == quote {
+				quote = 0
+			}
+		case c == '"' || c == '\'':
+			quote = c
+		case stop(c):
+			return i
+		}
+	}
+	return -1
+}
+
+// parseString parses the basic ("...") or literal ('...') string at the
+// start of raw and returns how many bytes it took up. Strings end on the
+// line they start on.
+func parseString(raw string) (string, int, error) {
+	quote := raw[0]
+	if strings.HasPrefix(raw, strings.Repeat(string(quote), 3)) {
+		return "", 0, fmt.Errorf("multi-line strings are not supported")
+	}
+
+	var b strings.Builder
+	for i := 1; i < len(raw); i++ {
+		c := raw[i]
+		switch {
+		case c == quote:
+			return b.String(), i + 1, nil
+		case c == '\n':
+			return "", 0, fmt.Errorf("unterminated string")
+		case c == '\\' && quote == '"':
+			i++
+			if i == len(raw) {
+				return "", 0, fmt.Errorf("unterminated string")
+			}
+			n, err := unescape(&b, raw[i:])
+			if err != nil {
+				return "", 0, err
+			}
+			i += n
+		default:
+			b.WriteByte(c)
+		}
+	}
+	return "", 0, fmt.Errorf("unterminated string")
+}
+
+// escapes maps the single-letter escapes of basic strings to their character
+var escapes = map[byte]byte{'b': '\b', 't': '\t', 'n': '\n', 'f': '\f', 'r': '\r', '"': '"', '\\': '\\'}
+
+// unescape writes the character of the escape sequence that follows a
+// backslash in raw and returns how many more bytes it took up
+func unescape(b *strings.Builder, raw string) (int, error) {
+	if c, ok := escapes[raw[0]]; ok {
+		b.WriteByte(c)
+		return 0, nil
+	}
+
+	digits := map[byte]int{'u': 4, 'U': 8}[raw[0]]
+	if digits == 0 {
+		r, _ := utf8.DecodeRuneInString(raw)
+		return 0, fmt.Errorf("unsupported escape \\%c", r)
+	}
+	if len(raw) <= digits {
+		return 0, fmt.Errorf("unterminated string")
+	}
+	code, err := strconv.ParseUint(raw[1:digits+1], 16, 32)
+	if err != nil || strings.ContainsAny(raw[1:digits+1], "+-_") || !utf8.ValidRune(rune(code)) {
+		return 0, fmt.Errorf("invalid escape \\%s", raw[:digits+1])
+	}
+	b.WriteRune(rune(code))
+	return digits, nil
+}
+
+// checkText makes sure the content is UTF-8 without control characters other
+// than tabs and line breaks, and turns Windows line breaks into plain ones
+func checkText(file, content string) (string, error) {
+	content = strings.ReplaceAll(content, "\r\n", "\n")
+	line := 1
+	for i, r := range content {
+		switch {
+		case r == '\n':
+			line++
+		case r == utf8.RuneError && !strings.HasPrefix(content[i:], "\uFFFD"):
+			return "", &Error{file, line, "the file is not valid UTF-8"}
+		case r < 0x20 && r != '\t' || r == 0x7f:
+			return "", &Error{file, line, fmt.Sprintf("control character %U is not allowed", r)}
+		}
+	}
+	return content, nil
+}
@@ -0,0 +1,87 @@
+package events
+
+import (
+	"encoding/json"
+	"io"
+	"sync"
+	"time"
+)
+
+// Type identifies the kind of event emitted while scanning or deleting
+type Type string
+
+// Event types shared by the TUI and the NDJSON stream
+const (
+	ScanRootStarted   Type = "scan_root_started"
+	MatchFound        Type = "match_found"
+	PermissionWarning Type = "permission_warning"
+	ItemDeleted       Type = "item_deleted"
+	ItemSkipped       Type = "item_skipped"
+	ItemFailed        Type = "item_failed"
+	ConflictFound     Type = "conflict_found"
+	Summary           Type = "summary"
+)
+
+// Event describes a single step of a scan or deletion
+type Event struct {
+	Type     Type      `json:"type"`
+	Time     time.Time `json:"time"`
+	App      string    `json:"app,omitempty"`
+	Path     string    `json:"path,omitempty"`
+	Root     string    `json:"root,omitempty"`
+	Category string    `json:"category,omitempty"`
+	Reason   string    `json:"reason,omitempty"`
+	Apps     []string  `json:"apps,omitempty"`
+	Error    string    `json:"error,omitempty"`
+	Totals   *Totals   `json:"totals,omitempty"`
+
+	// ForeignOwner marks items skipped because another user owns them
+	ForeignOwner bool `json:"foreign_owner,omitempty"`
+}
+
+// Totals is the payload of a Summary event
+type Totals struct {
+	Found   int  `json:"found"`
+	Deleted int  `json:"deleted"`
+	Skipped int  `json:"skipped"`
+	Failed  int  `json:"failed"`
+	DryRun  bool `json:"dry_run"`
+
+	// Cancelled is set when the run was interrupted before every item was handled
+	Cancelled bool `json:"cancelled,omitempty"`
+
+	// Categories lists the categories that were scanned when the scan was
+	// limited to some of them
+	Categories []string `json:"categories,omitempty"`
+
+	// ForeignOwned lists the items skipped because another user owns them
+	ForeignOwned []string `json:"foreign_owned,omitempty"`
+
+	// PerApp breaks the totals down by app when several apps were processed
+	PerApp map[string]*Totals `json:"per_app,omitempty"`
+}
+
+// Handler receives events as they are emitted
+type Handler func(Event)
+
+// Emit stamps the event and passes it to the handler. It is a no-op on a nil handler.
+func (h Handler) Emit(e Event) {
+	if h == nil {
+		return
+	}
+	if e.Time.IsZero() {
+		e.Time = time.Now()
+	}
+	h(e)
+}
+
+// NewNDJSONHandler returns a handler that writes one JSON object per line to w
+func NewNDJSONHandler(w io.Writer) Handler {
+	var mu sync.Mutex
+	enc := json.NewEncoder(w)
+	return func(e Event) {
+		mu.Lock()
+		defer mu.Unlock()
+		enc.Encode(e)
+	}
+}
@@ -0,0 +1,269 @@
+package finder
+
+import (
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/alexintosh/gocleaner/pkg/paths"
+	"github.com/alexintosh/gocleaner/pkg/policy"
+)
+
+// InstallSource describes how an application was installed
+type InstallSource string
+
+const (
+	SourceAppStore InstallSource = "app-store"
+	SourceHomebrew InstallSource = "homebrew"
+	SourceManual   InstallSource = "manual"
+)
+
+// Homebrew keeps installed casks in one of these directories
+var caskroomPaths = []string{
+	"/opt/homebrew/Caskroom",
+	"/usr/local/Caskroom",
+}
+
+// App describes an installed application bundle
+type App struct {
+	Name         string        `json:"name"`
+	BundleID     string        `json:"bundle_id"`
+	Version      string        `json:"version"`
+	Executable   string        `json:"executable,omitempty"`
+	Path         string        `json:"path"`
+	Location     string        `json:"location"`
+	Source       InstallSource `json:"source"`
+	Size         int64         `json:"size"`
+	Leftovers    []string      `json:"leftovers"`
+	LeftoverSize int64         `json:"leftover_size"`
+}
+
+// applicationLocations returns the standard locations for macOS applications
+func applicationLocations() []string {
+	locations := []string{
+		paths.Rebase("/Applications/"),
+		filepath.Join(paths.Home(), "Applications/"),
+	}
+	return append(locations, extraApplicationRoots...)
+}
+
+// ListApps indexes every app bundle in the application locations, including
+// bundles one folder deep such as /Applications/Utilities
+func ListApps() ([]App, error) {
+	casks := homebrewCaskApps()
+	apps := []App{}
+
+	for _, location := range applicationLocations() {
+		entries, err := os.ReadDir(location)
+		if err != nil {
+			continue
+		}
+
+		for _, entry := range entries {
+			path := filepath.Join(location, entry.Name())
+			if strings.HasSuffix(entry.Name(), ".app") {
+				apps = append(apps, readApp(path, location, casks))
+				continue
+			}
+
+			if !entry.IsDir() {
+				continue
+			}
+			nested, err := os.ReadDir(path)
+			if err != nil {
+				continue
+			}
+			for _, n := range nested {
+				if strings.HasSuffix(n.Name(), ".app") {
+					apps = append(apps, readApp(filepath.Join(path, n.Name()), location, casks))
+				}
+			}
+		}
+	}
+
+	sort.Slice(apps, func(i, j int) bool {
+		return strings.ToLower(apps[i].Name) < strings.ToLower(apps[j].Name)
+	})
+	return apps, nil
+}
+
+// ReadApp collects the metadata of the bundle at path, which was found in the
+// application location
+func ReadApp(path, location string) App {
+	return readApp(path, location, homebrewCaskApps())
+}
+
+// readApp collects the metadata of a bundle. casks maps app bundle names to
+// the Homebrew casks that installed them.
+func readApp(path, location string, casks map[string]string) App {
+	app := App{
+		Name:     strings.TrimSuffix(filepath.Base(path), ".app"),
+		Path:     path,
+		Location: filepath.Clean(location),
+		Source:   SourceManual,
+		Size:     PathSize(path),
+	}
+
+	if info, err := ReadInfoPlist(path); err == nil {
+		app.BundleID, _ = info["CFBundleIdentifier"].(string)
+		app.Executable, _ = info["CFBundleExecutable"].(string)
+		if version, ok := info["CFBundleShortVersionString"].(string); ok {
+			app.Version = version
+		} else {
+			app.Version, _ = info["CFBundleVersion"].(string)
+		}
+	}
+
+	// App Store installs carry a receipt inside the bundle
+	if _, err := os.Stat(filepath.Join(path, "Contents", "_MASReceipt", "receipt")); err == nil {
+		app.Source = SourceAppStore
+	} else if _, ok := casks[filepath.Base(path)]; ok {
+		app.Source = SourceHomebrew
+	}
+
+	return app
+}
+
+var caskAppPattern = regexp.MustCompile(`([^"'/]+\.app)["']`)
+
+// homebrewCaskApps maps app bundle names (e.g. "Firefox.app") to the cask
+// that installed them by reading the cask definitions Homebrew keeps in
+// each Caskroom entry's metadata
+func homebrewCaskApps() map[string]string {
+	casks := map[string]string{}
+
+	for _, caskroom := range caskroomPaths {
+		caskroom = paths.Rebase(caskroom)
+		tokens, err := os.ReadDir(caskroom)
+		if err != nil {
+			continue
+		}
+
+		for _, token := range tokens {
+			definitions, _ := filepath.Glob(filepath.Join(caskroom, token.Name(), ".metadata", "*", "*", "Casks", "*"))
+			for _, definition := range definitions {
+				content, err := os.ReadFile(definition)
+				if err != nil {
+					continue
+				}
+				for _, m := range caskAppPattern.FindAllSubmatch(content, -1) {
+					casks[string(m[1])] = token.Name()
+				}
+			}
+		}
+	}
+
+	return casks
+}
+
+// FindLeftovers scans the Library roots once and assigns every matching file
+// the policy allows deleting to the apps it belongs to. The result is keyed
+// by app path.
+func (f *AppFinder) FindLeftovers(apps []App) map[string][]string {
+	leftovers := map[string][]string{}
+	for _, libRoot := range libraryRoots() {
+		fullPath := libRoot.path
+		filepath.WalkDir(fullPath, func(path string, d fs.DirEntry, err error) error {
+			if err != nil {
+				return filepath.SkipDir
+			}
+			if path == fullPath {
+				return nil
+			}
+			if isIgnored(path) {
+				if d.IsDir() {
+					return filepath.SkipDir
+				}
+				return nil
+			}
+
+			baseName := filepath.Base(path)
+			matched := false
+			for _, app := range apps {
+				if !matchesApp(baseName, app.Name, app.BundleID) {
+					continue
+				}
+				matched = true
+				if configuredRoot(fullPath) {
+					policy.AllowMatch(path, fullPath)
+				}
+
+				// Like the finder, only count what an uninstall would delete
+				if verdict := policy.Evaluate(policy.SubjectFor(path, app.BundleID)); verdict.Allowed {
+					leftovers[app.Path] = append(leftovers[app.Path], path)
+				}
+			}
+
+			if matched && d.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		})
+	}
+
+	return leftovers
+}
+
+// PathSize returns the size of a file, or the total size of a directory tree
+func PathSize(path string) int64 {
+	var size int64
+	filepath.WalkDir(path, func(_ string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return nil
+		}
+		if info, err := d.Info(); err == nil && !info.IsDir() {
+			size += info.Size()
+		}
+		return nil
+	})
+	return size
+}
+
+// LocateApp finds the bundle of an app in the application locations without
+// scanning for any of its files. It returns the bundle path and its bundle
+// ID, which is empty when the Info.plist cannot be read.
+func LocateApp(appName string) (string, string, bool) {
+	for _, location := range applicationLocations() {
+		path, ok := bundleIn(location, appName)
+		if !ok {
+			path = filepath.Join(location, appName)
+			if info, err := os.Stat(path); err != nil || !info.IsDir() {
+				continue
+			}
+		}
+
+		bundleID := ""
+		if info, err := ReadInfoPlist(path); err == nil {
+			bundleID, _ = info["CFBundleIdentifier"].(string)
+		}
+		return path, bundleID, true
+	}
+	return "", "", false
+}
+
+// bundleIn looks for <appName>.app in an application location and, like
+// ListApps, in the folders directly inside it such as Utilities
+func bundleIn(location, appName string) (string, bool) {
+	bundle := appName + ".app"
+	if info, err := os.Stat(filepath.Join(location, bundle)); err == nil && info.IsDir() {
+		return filepath.Join(location, bundle), true
+	}
+
+	entries, err := os.ReadDir(location)
+	if err != nil {
+		return "", false
+	}
+	for _, entry := range entries {
+		if !entry.IsDir() || strings.HasSuffix(entry.Name(), ".app") {
+			continue
+		}
+		path := filepath.Join(location, entry.Name(), bundle)
+		if info, err := os.Stat(path); err == nil && info.IsDir() {
+			return path, true
+		}
+	}
+	return "", false
+}
@@ -0,0 +1,59 @@
+package finder
+
+// AppMatches holds the files found for one app of a batch
+type AppMatches struct {
+	App     string  `json:"app"`
+	Matches []Match `json:"matches"`
+}
+
+// Conflict is a path claimed by more than one app of a batch
+type Conflict struct {
+	Path string   `json:"path"`
+	Apps []string `json:"apps"`
+}
+
+// FindBatch scans for every app up front, using a fresh finder from newFinder
+// per app so no state leaks between them. Paths claimed by several apps are
+// reported as conflicts and kept only under the first app that claimed them.
+func FindBatch(appNames []string, newFinder func() *AppFinder) ([]AppMatches, []Conflict, error) {
+	results := make([]AppMatches, 0, len(appNames))
+	for _, appName := range appNames {
+		matches, err := newFinder().FindMatches(appName)
+		if err != nil {
+			return nil, nil, err
+		}
+		results = append(results, AppMatches{App: appName, Matches: matches})
+	}
+
+	owners := map[string][]string{}
+	order := []string{}
+	for i, result := range results {
+		kept := []Match{}
+		for _, m := range result.Matches {
+			if len(owners[m.Path]) == 0 {
+				order = append(order, m.Path)
+				kept = append(kept, m)
+			}
+			owners[m.Path] = append(owners[m.Path], result.App)
+		}
+		results[i].Matches = kept
+	}
+
+	conflicts := []Conflict{}
+	for _, path := range order {
+		if len(owners[path]) > 1 {
+			conflicts = append(conflicts, Conflict{Path: path, Apps: owners[path]})
+		}
+	}
+
+	return results, conflicts, nil
+}
+
+// Paths returns the paths of the matches
+func Paths(matches []Match) []string {
+	paths := make([]string, 0, len(matches))
+	for _, m := range matches {
+		paths = append(paths, m.Path)
+	}
+	return paths
+}
@@ -0,0 +1,85 @@
+package finder
+
+import (
+	"io/fs"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+// Helper is a bundle nested inside an app, such as a login item or XPC service
+type Helper struct {
+	Path     string `json:"path"`
+	BundleID string `json:"bundle_id"`
+}
+
+// Signature holds the code signing details of a bundle
+type Signature struct {
+	Signed    bool     `json:"signed"`
+	TeamID    string   `json:"team_id,omitempty"`
+	Authority []string `json:"authority,omitempty"`
+}
+
+// helperExtensions are the bundle types that can hold their own bundle ID
+var helperExtensions = []string{".app", ".xpc", ".appex", ".plugin", ".bundle"}
+
+// FindHelpers lists the bundles nested inside an app bundle. Paths are
+// relative to the app bundle.
+func FindHelpers(appPath string) []Helper {
+	helpers := []Helper{}
+	contents := filepath.Join(appPath, "Contents")
+
+	filepath.WalkDir(contents, func(path string, d fs.DirEntry, err error) error {
+		if err != nil || !d.IsDir() || path == contents {
+			return nil
+		}
+
+		// Frameworks are shared code, not helpers with their own data
+		if strings.HasSuffix(path, ".framework") {
+			return filepath.SkipDir
+		}
+
+		for _, ext := range helperExtensions {
+			if !strings.HasSuffix(path, ext) {
+				continue
+			}
+			if info, err := ReadInfoPlist(path); err == nil {
+				if bundleID, ok := info["CFBundleIdentifier"].(string); ok {
+					rel, _ := filepath.Rel(appPath, path)
+					helpers = append(helpers, Helper{Path: rel, BundleID: bundleID})
+				}
+			}
+			break
+		}
+		return nil
+	})
+
+	return helpers
+}
+
+// ReadSignature asks codesign for the signing details of a bundle. An
+// unsigned bundle, or a system without codesign, reports Signed as false.
+func ReadSignature(bundlePath string) Signature {
+	// codesign writes the details to stderr
+	output, err := exec.Command("codesign", "-dv", "--verbose=2", bundlePath).CombinedOutput()
+	if err != nil {
+		return Signature{}
+	}
+
+	sig := Signature{Signed: true}
+	for _, line := range strings.Split(string(output), "\n") {
+		key, value, ok := strings.Cut(line, "=")
+		if !ok {
+			continue
+		}
+		switch key {
+		case "TeamIdentifier":
+			if value != "not set" {
+				sig.TeamID = value
+			}
+		case "Authority":
+			sig.Authority = append(sig.Authority, value)
+		}
+	}
+	return sig
+}
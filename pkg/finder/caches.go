@@ -0,0 +1,97 @@
+package finder
+
+import (
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"github.com/alexintosh/gocleaner/pkg/paths"
+)
+
+// CacheEntry is a top-level entry of a cache directory
+type CacheEntry struct {
+	Path     string    `json:"path"`
+	Root     string    `json:"root"`
+	Owner    string    `json:"owner"`
+	BundleID string    `json:"bundle_id,omitempty"`
+	Size     int64     `json:"size"`
+	Modified time.Time `json:"modified"`
+}
+
+// CacheRoots returns the cache directories of the targeted user: the Library
+// cache and, when available, the per-user cache under /private/var/folders
+func CacheRoots() []string {
+	roots := []string{filepath.Join(paths.Home(), "Library", "Caches")}
+	if dir := paths.DarwinUserCacheDir(); dir != "" {
+		roots = append(roots, dir)
+	}
+	return roots
+}
+
+// ScanCaches lists the entries of every cache root with their size and the
+// time anything inside them was last modified. Entries are attributed to the
+// installed app they belong to, falling back to the entry name, which for
+// most caches is the owner's bundle ID.
+func ScanCaches(apps []App) []CacheEntry {
+	entries := []CacheEntry{}
+
+	for _, root := range CacheRoots() {
+		dirEntries, err := os.ReadDir(root)
+		if err != nil {
+			continue
+		}
+
+		for _, d := range dirEntries {
+			entry := CacheEntry{
+				Path:  filepath.Join(root, d.Name()),
+				Root:  root,
+				Owner: d.Name(),
+			}
+			entry.Size, entry.Modified = sizeAndModified(entry.Path)
+
+			// Only confident matches are used, a cache merely containing a
+			// short app name is more likely to belong to something else
+			for _, app := range apps {
+				if _, confidence, ok := matchReason(d.Name(), app.Name, app.BundleID); ok && confidence == ConfidenceHigh {
+					entry.Owner = app.Name
+					entry.BundleID = app.BundleID
+					break
+				}
+			}
+			entries = append(entries, entry)
+		}
+	}
+
+	sort.SliceStable(entries, func(i, j int) bool {
+		return entries[i].Owner < entries[j].Owner
+	})
+	return entries
+}
+
+// sizeAndModified returns the total size of a path and the most recent
+// modification time of anything inside it
+func sizeAndModified(path string) (int64, time.Time) {
+	var size int64
+	var modified time.Time
+
+	filepath.WalkDir(path, func(_ string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return nil
+		}
+		info, err := d.Info()
+		if err != nil {
+			return nil
+		}
+		if info.ModTime().After(modified) {
+			modified = info.ModTime()
+		}
+		if !info.IsDir() {
+			size += info.Size()
+		}
+		return nil
+	})
+
+	return size, modified
+}
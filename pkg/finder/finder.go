@@ -6,16 +6,92 @@ import (
 	"os"
 	"path/filepath"
 	"strings"
+
+	"github.com/alexintosh/gocleaner/pkg/events"
+	"github.com/alexintosh/gocleaner/pkg/policy"
+	"github.com/alexintosh/gocleaner/pkg/paths"
 )
 
 // Paths to scan for app-related files
-var macOSLibraryPaths = []string{
-	"Library/Application Support/",
-	"Library/Preferences/",
-	"Library/Caches/",
-	"Library/Logs/",
-	"Library/Containers/",
-	"Library/Saved Application State/",
+var macOSLibraryPaths = []libraryRoot{
+	{"Library/Application Support/", CategorySupport},
+	{"Library/Preferences/", CategoryPreferences},
+	{"Library/Caches/", CategoryCaches},
+	{"Library/Logs/", CategoryLogs},
+	{"Library/Containers/", CategoryContainers},
+	{"Library/Saved Application State/", CategoryState},
+}
+
+var (
+	// extraLibraryRoots are absolute directories added to the scan
+	extraLibraryRoots []libraryRoot
+
+	// extraApplicationRoots are absolute directories searched for app bundles
+	extraApplicationRoots []string
+
+	// ignorePatterns exclude paths from every scan. Patterns containing a
+	// slash match the whole path, others only the file name.
+	ignorePatterns []string
+)
+
+// AddScanRoots adds absolute directories to scan for app-related files.
+// Files found in them belong to CategoryOther.
+func AddScanRoots(roots ...string) {
+	for _, root := range roots {
+		extraLibraryRoots = append(extraLibraryRoots, libraryRoot{root, CategoryOther})
+	}
+}
+
+// AddApplicationRoots adds absolute directories to search for app bundles
+func AddApplicationRoots(roots ...string) {
+	extraApplicationRoots = append(extraApplicationRoots, roots...)
+}
+
+// AddIgnorePatterns adds glob patterns for paths that are never matched
+func AddIgnorePatterns(patterns ...string) {
+	ignorePatterns = append(ignorePatterns, patterns...)
+}
+
+// configuredRoot reports whether a directory is a scan or application root
+// added through the configuration file
+func configuredRoot(dir string) bool {
+	dir = filepath.Clean(dir)
+	for _, root := range extraLibraryRoots {
+		if filepath.Clean(root.path) == dir {
+			return true
+		}
+	}
+	for _, root := range extraApplicationRoots {
+		if filepath.Clean(root) == dir {
+			return true
+		}
+	}
+	return false
+}
+
+// isIgnored checks if a path matches one of the ignore patterns
+func isIgnored(path string) bool {
+	for _, pattern := range ignorePatterns {
+		target := filepath.Base(path)
+		if strings.Contains(pattern, "/") {
+			target = path
+		}
+		if ok, _ := filepath.Match(pattern, target); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// libraryRoots returns every directory to scan as an absolute path in the
+// targeted home directory
+func libraryRoots() []libraryRoot {
+	homeDir := paths.Home()
+	roots := make([]libraryRoot, 0, len(macOSLibraryPaths)+len(extraLibraryRoots))
+	for _, root := range macOSLibraryPaths {
+		roots = append(roots, libraryRoot{filepath.Join(homeDir, root.path), root.category})
+	}
+	return append(roots, extraLibraryRoots...)
 }
 
 type AppFinder struct {
@@ -23,6 +99,9 @@ type AppFinder struct {
 	bundleID  string
 	appName   string
 	foundFiles []string
+	matches   []Match
+	categories []Category
+	onEvent   events.Handler
 }
 
 // NewAppFinder creates a new AppFinder instance
@@ -33,8 +112,42 @@ func NewAppFinder(verbose bool) *AppFinder {
 	}
 }
 
+// SetEventHandler registers a handler that receives scan events as they happen
+func (f *AppFinder) SetEventHandler(h events.Handler) {
+	f.onEvent = h
+}
+
+// SetCategories restricts the search to files in the given categories. The
+// app bundle is still located to learn its bundle ID, but is only returned
+// when CategoryApplication is included. A nil slice means every category.
+func (f *AppFinder) SetCategories(categories []Category) {
+	f.categories = categories
+}
+
+// includes reports whether files of the category are being searched for
+func (f *AppFinder) includes(category Category) bool {
+	if f.categories == nil {
+		return true
+	}
+	for _, c := range f.categories {
+		if c == category {
+			return true
+		}
+	}
+	return false
+}
+
 // FindAllAssociatedFiles finds the app bundle and all associated files
 func (f *AppFinder) FindAllAssociatedFiles(appName string) ([]string, error) {
+	if _, err := f.FindMatches(appName); err != nil {
+		return nil, err
+	}
+	return f.foundFiles, nil
+}
+
+// FindMatches finds the app bundle and all associated files, along with the
+// category, reason and confidence of every match
+func (f *AppFinder) FindMatches(appName string) ([]Match, error) {
 	f.appName = appName
 	
 	// Search for app bundle in standard locations
@@ -47,29 +160,43 @@ func (f *AppFinder) FindAllAssociatedFiles(appName string) ([]string, error) {
 		return nil, err
 	}
 	
-	return f.foundFiles, nil
+	return f.matches, nil
 }
 
-// findAppBundle searches for the app bundle in standard locations
-func (f *AppFinder) findAppBundle() error {
-	// Standard locations for macOS applications
-	appLocations := []string{
-		"/Applications/",
-		filepath.Join(os.Getenv("HOME"), "Applications/"),
+// addMatch records a found file and reports it to the event handler
+func (f *AppFinder) addMatch(m Match) {
+	if !f.includes(m.Category) || isIgnored(m.Path) {
+		return
 	}
 	
+	// No rule covers a configured root, so what was matched there is allowed by name
+	if configuredRoot(m.Root) {
+		policy.AllowMatch(m.Path, m.Root)
+	}
+
+	// Paths the policy denies are never offered for deletion
+	if verdict := policy.Evaluate(policy.SubjectFor(m.Path, f.bundleID)); !verdict.Allowed {
+		if f.verbose {
+			fmt.Printf("Skipping %s: %s\n", m.Path, verdict.Reason)
+		}
+		return
+	}
+	f.foundFiles = append(f.foundFiles, m.Path)
+	f.matches = append(f.matches, m)
+	f.onEvent.Emit(events.Event{Type: events.MatchFound, App: f.appName, Path: m.Path, Root: m.Root, Category: string(m.Category), Reason: m.Reason})
+}
+
+// findAppBundle searches for the app bundle in standard locations
+func (f *AppFinder) findAppBundle() error {
 	appFound := false
 	
-	for _, location := range appLocations {
-		// Try with standard .app extension
-		appPath := filepath.Join(location, f.appName+".app")
+	for _, location := range applicationLocations() {
+		// Try with standard .app extension, also one folder deep like ListApps
 		if f.verbose {
-			fmt.Printf("Checking for app bundle at: %s\n", appPath)
+			fmt.Printf("Checking for %s.app in: %s\n", f.appName, location)
 		}
 		
-		if _, err := os.Stat(appPath); err == nil {
-			// App found, add to found files
-			f.foundFiles = append(f.foundFiles, appPath)
+		if appPath, ok := bundleIn(location, f.appName); ok {
 			appFound = true
 			
 			// Try to extract bundle ID
@@ -85,18 +212,21 @@ func (f *AppFinder) findAppBundle() error {
 				}
 			}
 			
+			// App found, add to found files now that the policy can see its bundle ID
+			f.addMatch(Match{Path: appPath, Root: filepath.Clean(location), Category: CategoryApplication, Reason: ReasonAppBundle, Confidence: ConfidenceHigh})
+			
 			break
 		}
 		
 		// If not found, try without .app extension (for non-standard app directories)
-		appPath = filepath.Join(location, f.appName)
+		appPath := filepath.Join(location, f.appName)
 		if f.verbose {
 			fmt.Printf("Checking for app directory at: %s\n", appPath)
 		}
 		
 		if info, err := os.Stat(appPath); err == nil && info.IsDir() {
 			// App directory found, add to found files
-			f.foundFiles = append(f.foundFiles, appPath)
+			f.addMatch(Match{Path: appPath, Root: filepath.Clean(location), Category: CategoryApplication, Reason: ReasonAppBundle, Confidence: ConfidenceHigh})
 			appFound = true
 			
 			// For non-standard app directories, we may not be able to extract bundle ID
@@ -130,13 +260,15 @@ func (f *AppFinder) extractBundleID(appPath string) (string, error) {
 
 // findAssociatedFiles searches for app-related files in standard macOS directories
 func (f *AppFinder) findAssociatedFiles() error {
-	homeDir := os.Getenv("HOME")
-	
-	for _, libPath := range macOSLibraryPaths {
-		fullPath := filepath.Join(homeDir, libPath)
+	for _, libRoot := range libraryRoots() {
+		if !f.includes(libRoot.category) {
+			continue
+		}
+		fullPath := libRoot.path
 		if f.verbose {
 			fmt.Printf("Scanning directory: %s\n", fullPath)
 		}
+		f.onEvent.Emit(events.Event{Type: events.ScanRootStarted, App: f.appName, Root: fullPath})
 		
 		// Walk through the directory and find matches
 		if err := filepath.WalkDir(fullPath, func(path string, d fs.DirEntry, err error) error {
@@ -145,6 +277,9 @@ func (f *AppFinder) findAssociatedFiles() error {
 				if f.verbose {
 					fmt.Printf("Warning: Could not access %s: %v\n", path, err)
 				}
+				if os.IsPermission(err) {
+					f.onEvent.Emit(events.Event{Type: events.PermissionWarning, App: f.appName, Path: path, Root: fullPath, Error: err.Error()})
+				}
 				return filepath.SkipDir
 			}
 			
@@ -153,9 +288,17 @@ func (f *AppFinder) findAssociatedFiles() error {
 				return nil
 			}
 			
+			// Ignored paths are left alone along with everything inside them
+			if isIgnored(path) {
+				if d.IsDir() {
+					return filepath.SkipDir
+				}
+				return nil
+			}
+			
 			// Check if the file/directory matches our app
-			if f.isRelatedToApp(path) {
-				f.foundFiles = append(f.foundFiles, path)
+			if reason, confidence, ok := matchReason(filepath.Base(path), f.appName, f.bundleID); ok {
+				f.addMatch(Match{Path: path, Root: fullPath, Category: libRoot.category, Reason: reason, Confidence: confidence})
 				if f.verbose {
 					fmt.Printf("Found related file: %s\n", path)
 				}
@@ -177,18 +320,8 @@ func (f *AppFinder) findAssociatedFiles() error {
 	return nil
 }
 
-// isRelatedToApp checks if a file or directory is related to the app
-func (f *AppFinder) isRelatedToApp(path string) bool {
-	baseName := filepath.Base(path)
-	
-	// Check for bundle ID match
-	if f.bundleID != "" && strings.Contains(baseName, f.bundleID) {
-		return true
-	}
-	
-	// Check for app name match (case-insensitive)
-	appNameLower := strings.ToLower(f.appName)
-	baseNameLower := strings.ToLower(baseName)
-	
-	return strings.Contains(baseNameLower, appNameLower)
-} 
\ No newline at end of file
+// matchesApp checks if a file name refers to the app by bundle ID or name
+func matchesApp(baseName, appName, bundleID string) bool {
+	_, _, ok := matchReason(baseName, appName, bundleID)
+	return ok
+}
@@ -0,0 +1,63 @@
+package finder
+
+import (
+	"path/filepath"
+	"strings"
+)
+
+// ScanLocation is a directory searched for app files along with the category
+// of the files found in it
+type ScanLocation struct {
+	Path     string
+	Category Category
+}
+
+// ScanLocations returns the application folders and Library directories that
+// are scanned, in the targeted home directory
+func ScanLocations() []ScanLocation {
+	locations := []ScanLocation{}
+	for _, location := range applicationLocations() {
+		locations = append(locations, ScanLocation{filepath.Clean(location), CategoryApplication})
+	}
+	for _, root := range libraryRoots() {
+		locations = append(locations, ScanLocation{filepath.Clean(root.path), root.category})
+	}
+	return locations
+}
+
+// IncludedMatch describes a path added by hand. It belongs to the innermost
+// scan location holding it, or to CategoryOther under its parent directory.
+func IncludedMatch(path string) Match {
+	m := Match{Path: path, Root: filepath.Dir(path), Category: CategoryOther, Reason: ReasonIncluded, Confidence: ConfidenceHigh}
+	best := ""
+	for _, location := range ScanLocations() {
+		if strings.HasPrefix(path, location.Path+"/") && len(location.Path) > len(best) {
+			best = location.Path
+			m.Root = location.Path
+			m.Category = location.Category
+		}
+	}
+	return m
+}
+
+// IncludeMatches adds matches chosen by hand to the first app of a batch,
+// leaving out the ones any app has already
+func IncludeMatches(results []AppMatches, matches []Match) []AppMatches {
+	if len(results) == 0 || len(matches) == 0 {
+		return results
+	}
+
+	known := map[string]bool{}
+	for _, result := range results {
+		for _, m := range result.Matches {
+			known[m.Path] = true
+		}
+	}
+	for _, m := range matches {
+		if !known[m.Path] {
+			known[m.Path] = true
+			results[0].Matches = append(results[0].Matches, m)
+		}
+	}
+	return results
+}
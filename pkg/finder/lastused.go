@@ -0,0 +1,40 @@
+package finder
+
+import (
+	"os/exec"
+	"strings"
+	"time"
+)
+
+// mdlsDateLayout is how mdls prints dates
+const mdlsDateLayout = "2006-01-02 15:04:05 -0700"
+
+// LastUsedDates asks Spotlight when each app was last opened. The result is
+// keyed by app path and leaves out the apps Spotlight has no date for.
+func LastUsedDates(apps []App) map[string]time.Time {
+	dates := map[string]time.Time{}
+	if len(apps) == 0 {
+		return dates
+	}
+
+	args := []string{"-raw", "-name", "kMDItemLastUsedDate"}
+	for _, app := range apps {
+		args = append(args, app.Path)
+	}
+
+	// With -raw the values of several files are separated by NUL bytes
+	output, err := exec.Command("mdls", args...).Output()
+	if err != nil {
+		return dates
+	}
+	values := strings.Split(string(output), "\x00")
+	for i, app := range apps {
+		if i >= len(values) {
+			break
+		}
+		if date, err := time.Parse(mdlsDateLayout, strings.TrimSpace(values[i])); err == nil {
+			dates[app.Path] = date
+		}
+	}
+	return dates
+}
@@ -0,0 +1,108 @@
+package finder
+
+import (
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/alexintosh/gocleaner/pkg/paths"
+)
+
+// Kinds of log entries
+const (
+	LogKindLog         = "log"
+	LogKindCrashReport = "crash report"
+)
+
+// LogEntry is a log file, log folder or diagnostic report
+type LogEntry struct {
+	Path     string    `json:"path"`
+	Owner    string    `json:"owner"`
+	BundleID string    `json:"bundle_id,omitempty"`
+	Kind     string    `json:"kind"`
+	Size     int64     `json:"size"`
+	Modified time.Time `json:"modified"`
+}
+
+// crashReportPattern matches diagnostic report names, which start with the
+// executable name followed by a timestamp, e.g. Safari_2024-01-31-101500_host.crash
+// or Safari-2024-01-31-101500.ips
+var crashReportPattern = regexp.MustCompile(`^(.+?)[_-](\d{4}-\d{2}-\d{2}-\d{6})`)
+
+// diagnosticExtensions are the file types written to DiagnosticReports
+var diagnosticExtensions = map[string]bool{
+	".crash": true,
+	".ips":   true,
+	".diag":  true,
+	".hang":  true,
+	".spin":  true,
+}
+
+// LogRoots returns the log directory and diagnostic reports directory of the targeted user
+func LogRoots() (string, string) {
+	logs := filepath.Join(paths.Home(), "Library", "Logs")
+	return logs, filepath.Join(logs, "DiagnosticReports")
+}
+
+// ScanLogs lists the user's logs and diagnostic reports, attributed to the
+// installed app they belong to. Crash reports are matched on the executable
+// name in the file name, log folders like other leftovers on bundle ID or
+// app name. Entries without a matching app are owned by their own name.
+func ScanLogs(apps []App) []LogEntry {
+	logsDir, reportsDir := LogRoots()
+	entries := []LogEntry{}
+
+	if dirEntries, err := os.ReadDir(logsDir); err == nil {
+		for _, d := range dirEntries {
+			path := filepath.Join(logsDir, d.Name())
+			if path == reportsDir {
+				continue
+			}
+
+			entry := LogEntry{Path: path, Owner: d.Name(), Kind: LogKindLog}
+			entry.Size, entry.Modified = sizeAndModified(path)
+			for _, app := range apps {
+				if _, confidence, ok := matchReason(d.Name(), app.Name, app.BundleID); ok && confidence == ConfidenceHigh {
+					entry.Owner, entry.BundleID = app.Name, app.BundleID
+					break
+				}
+			}
+			entries = append(entries, entry)
+		}
+	}
+
+	if dirEntries, err := os.ReadDir(reportsDir); err == nil {
+		for _, d := range dirEntries {
+			if d.IsDir() || !diagnosticExtensions[filepath.Ext(d.Name())] {
+				continue
+			}
+
+			path := filepath.Join(reportsDir, d.Name())
+			entry := LogEntry{Path: path, Owner: d.Name(), Kind: LogKindCrashReport}
+			entry.Size, entry.Modified = sizeAndModified(path)
+
+			if m := crashReportPattern.FindStringSubmatch(d.Name()); m != nil {
+				entry.Owner = m[1]
+				for _, app := range apps {
+					if strings.EqualFold(app.Executable, m[1]) || strings.EqualFold(app.Name, m[1]) {
+						entry.Owner, entry.BundleID = app.Name, app.BundleID
+						break
+					}
+				}
+			}
+			entries = append(entries, entry)
+		}
+	}
+
+	// Group by owner, newest first within each owner
+	sort.SliceStable(entries, func(i, j int) bool {
+		if entries[i].Owner != entries[j].Owner {
+			return entries[i].Owner < entries[j].Owner
+		}
+		return entries[i].Modified.After(entries[j].Modified)
+	})
+	return entries
+}
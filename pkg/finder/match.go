@@ -0,0 +1,121 @@
+package finder
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+)
+
+// Category groups found files by the kind of location they live in
+type Category string
+
+const (
+	CategoryApplication Category = "application"
+	CategorySupport     Category = "support"
+	CategoryPreferences Category = "preferences"
+	CategoryCaches      Category = "caches"
+	CategoryLogs        Category = "logs"
+	CategoryContainers  Category = "containers"
+	CategoryState       Category = "state"
+
+	// CategoryOther holds scan roots added through the configuration file
+	CategoryOther Category = "other"
+)
+
+// Categories lists every category in display order
+var Categories = []Category{
+	CategoryApplication,
+	CategorySupport,
+	CategoryPreferences,
+	CategoryCaches,
+	CategoryLogs,
+	CategoryContainers,
+	CategoryState,
+	CategoryOther,
+}
+
+// categoryAliases are alternative spellings accepted by ParseCategory
+var categoryAliases = map[string]Category{
+	"app":                 CategoryApplication,
+	"bundle":              CategoryApplication,
+	"application-support": CategorySupport,
+	"prefs":               CategoryPreferences,
+	"cache":               CategoryCaches,
+	"log":                 CategoryLogs,
+	"container":           CategoryContainers,
+	"saved-state":         CategoryState,
+}
+
+// ParseCategory converts a category name as typed on the command line
+func ParseCategory(name string) (Category, error) {
+	name = strings.ToLower(strings.TrimSpace(name))
+	for _, c := range Categories {
+		if string(c) == name {
+			return c, nil
+		}
+	}
+	if c, ok := categoryAliases[name]; ok {
+		return c, nil
+	}
+
+	names := make([]string, 0, len(Categories))
+	for _, c := range Categories {
+		names = append(names, string(c))
+	}
+	return "", fmt.Errorf("unknown category %q (expected one of %s)", name, strings.Join(names, ", "))
+}
+
+// Confidence tells how certain a match is to belong to the app
+type Confidence string
+
+const (
+	ConfidenceHigh Confidence = "high"
+	ConfidenceLow  Confidence = "low"
+)
+
+// Reasons a file was matched to an app
+const (
+	ReasonAppBundle    = "app bundle"
+	ReasonBundleID     = "bundle ID"
+	ReasonExactName    = "app name"
+	ReasonNameContains = "name contains app name"
+	ReasonIncluded     = "added by hand"
+)
+
+// Match is a file or directory found for an app, with where and why it was found
+type Match struct {
+	Path       string     `json:"path"`
+	Root       string     `json:"root"`
+	Category   Category   `json:"category"`
+	Reason     string     `json:"reason"`
+	Confidence Confidence `json:"confidence"`
+}
+
+// libraryRoot is a Library directory to scan and the category of its contents
+type libraryRoot struct {
+	path     string
+	category Category
+}
+
+// matchReason explains why a file name refers to the app, if it does
+func matchReason(baseName, appName, bundleID string) (string, Confidence, bool) {
+	// Check for bundle ID match
+	if bundleID != "" && strings.Contains(baseName, bundleID) {
+		return ReasonBundleID, ConfidenceHigh, true
+	}
+
+	// Check for app name match (case-insensitive)
+	appNameLower := strings.ToLower(appName)
+	baseNameLower := strings.ToLower(baseName)
+	if !strings.Contains(baseNameLower, appNameLower) {
+		return "", "", false
+	}
+
+	// A name that is exactly the app name (ignoring the extension) is far less
+	// likely to belong to something else than one merely containing it
+	stem := strings.TrimSuffix(baseNameLower, strings.ToLower(filepath.Ext(baseName)))
+	if baseNameLower == appNameLower || stem == appNameLower {
+		return ReasonExactName, ConfidenceHigh, true
+	}
+	return ReasonNameContains, ConfidenceLow, true
+}
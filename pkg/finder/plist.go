@@ -1,63 +1,158 @@
 package finder
 
 import (
+	"bytes"
 	"encoding/xml"
 	"fmt"
 	"io"
 	"os"
+	"os/exec"
 	"path/filepath"
+	"strconv"
+	"strings"
 )
 
-// SimplePlist represents the basic structure of a plist file
-type SimplePlist struct {
-	Dict PlistDict `xml:"dict"`
-}
+// ParseBundleID extracts the bundle ID from an app's Info.plist file
+func (f *AppFinder) ParseBundleID(appPath string) (string, error) {
+	info, err := ReadInfoPlist(appPath)
+	if err != nil {
+		return "", err
+	}
 
-// PlistDict represents a dictionary in a plist file
-type PlistDict struct {
-	Keys   []string    `xml:"key"`
-	Values []PlistItem `xml:",any"`
+	if bundleID, ok := info["CFBundleIdentifier"].(string); ok && bundleID != "" {
+		return bundleID, nil
+	}
+
+	return "", fmt.Errorf("bundle ID not found in Info.plist")
 }
 
-// PlistItem is an interface for plist values
-type PlistItem interface{}
+// ReadInfoPlist reads and parses the Contents/Info.plist file of a bundle
+func ReadInfoPlist(bundlePath string) (map[string]interface{}, error) {
+	infoPlistPath := filepath.Join(bundlePath, "Contents", "Info.plist")
 
-// ParseBundleID extracts the bundle ID from an app's Info.plist file
-func (f *AppFinder) ParseBundleID(appPath string) (string, error) {
-	infoPlistPath := filepath.Join(appPath, "Contents", "Info.plist")
-	
 	// Check if Info.plist exists
 	if _, err := os.Stat(infoPlistPath); err != nil {
-		return "", fmt.Errorf("Info.plist not found: %w", err)
+		return nil, fmt.Errorf("Info.plist not found: %w", err)
 	}
-	
-	// Open the file
-	file, err := os.Open(infoPlistPath)
+
+	return ReadPlist(infoPlistPath)
+}
+
+// ReadPlist parses a property list file into a dictionary. XML plists are
+// parsed directly; binary plists are converted with plutil first.
+func ReadPlist(path string) (map[string]interface{}, error) {
+	content, err := os.ReadFile(path)
 	if err != nil {
-		return "", fmt.Errorf("failed to open Info.plist: %w", err)
+		return nil, fmt.Errorf("failed to read %s: %w", filepath.Base(path), err)
+	}
+
+	if bytes.HasPrefix(content, []byte("bplist")) {
+		content, err = exec.Command("plutil", "-convert", "xml1", "-o", "-", path).Output()
+		if err != nil {
+			return nil, fmt.Errorf("failed to convert binary %s: %w", filepath.Base(path), err)
+		}
 	}
-	defer file.Close()
-	
-	// Read the file content
-	content, err := io.ReadAll(file)
+
+	value, err := parsePlistXML(content)
 	if err != nil {
-		return "", fmt.Errorf("failed to read Info.plist: %w", err)
-	}
-	
-	// Parse XML
-	var plist SimplePlist
-	if err := xml.Unmarshal(content, &plist); err != nil {
-		return "", fmt.Errorf("failed to parse Info.plist: %w", err)
-	}
-	
-	// Find the bundle ID
-	for i, key := range plist.Dict.Keys {
-		if key == "CFBundleIdentifier" && i < len(plist.Dict.Values) {
-			if bundleID, ok := plist.Dict.Values[i+1].(string); ok {
-				return bundleID, nil
+		return nil, fmt.Errorf("failed to parse %s: %w", filepath.Base(path), err)
+	}
+
+	dict, ok := value.(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("failed to parse %s: top level value is not a dictionary", filepath.Base(path))
+	}
+	return dict, nil
+}
+
+// parsePlistXML decodes the root value of an XML property list
+func parsePlistXML(content []byte) (interface{}, error) {
+	decoder := xml.NewDecoder(bytes.NewReader(content))
+	// Info.plist files declare a DTD we have no use for
+	decoder.Strict = false
+
+	for {
+		token, err := decoder.Token()
+		if err != nil {
+			if err == io.EOF {
+				return nil, fmt.Errorf("no plist value found")
 			}
+			return nil, err
+		}
+		if start, ok := token.(xml.StartElement); ok && start.Name.Local != "plist" {
+			return decodePlistValue(decoder, start)
 		}
 	}
-	
-	return "", fmt.Errorf("bundle ID not found in Info.plist")
-} 
\ No newline at end of file
+}
+
+// decodePlistValue decodes the value that begins with start
+func decodePlistValue(decoder *xml.Decoder, start xml.StartElement) (interface{}, error) {
+	switch start.Name.Local {
+	case "dict":
+		dict := map[string]interface{}{}
+		key := ""
+		for {
+			token, err := decoder.Token()
+			if err != nil {
+				return nil, err
+			}
+			switch t := token.(type) {
+			case xml.StartElement:
+				if t.Name.Local == "key" {
+					if err := decoder.DecodeElement(&key, &t); err != nil {
+						return nil, err
+					}
+					continue
+				}
+				value, err := decodePlistValue(decoder, t)
+				if err != nil {
+					return nil, err
+				}
+				dict[key] = value
+			case xml.EndElement:
+				return dict, nil
+			}
+		}
+
+	case "array":
+		array := []interface{}{}
+		for {
+			token, err := decoder.Token()
+			if err != nil {
+				return nil, err
+			}
+			switch t := token.(type) {
+			case xml.StartElement:
+				value, err := decodePlistValue(decoder, t)
+				if err != nil {
+					return nil, err
+				}
+				array = append(array, value)
+			case xml.EndElement:
+				return array, nil
+			}
+		}
+
+	case "true", "false":
+		if err := decoder.Skip(); err != nil {
+			return nil, err
+		}
+		return start.Name.Local == "true", nil
+	}
+
+	var text string
+	if err := decoder.DecodeElement(&text, &start); err != nil {
+		return nil, err
+	}
+	text = strings.TrimSpace(text)
+
+	switch start.Name.Local {
+	case "integer":
+		return strconv.ParseInt(text, 10, 64)
+	case "real":
+		return strconv.ParseFloat(text, 64)
+	}
+
+	// string, date and data values are kept as text
+	return text, nil
+}
@@ -0,0 +1,41 @@
+package paths
+
+import (
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+var (
+	userCacheDir     string
+	userCacheDirOnce sync.Once
+)
+
+// DarwinUserCacheDir returns the per-user cache directory macOS keeps under
+// /private/var/folders, or an empty string when it cannot be determined. It
+// only exists for the current user on the running system, so it is also
+// empty when a root or another user is targeted.
+func DarwinUserCacheDir() string {
+	if !isDefault() {
+		return ""
+	}
+	userCacheDirOnce.Do(func() {
+		output, err := exec.Command("getconf", "DARWIN_USER_CACHE_DIR").Output()
+		if err != nil {
+			return
+		}
+
+		dir := strings.TrimSpace(string(output))
+		if dir == "" {
+			return
+		}
+
+		// getconf reports /var/folders/..., but /var is a symlink to /private/var
+		if resolved, err := filepath.EvalSymlinks(dir); err == nil {
+			dir = resolved
+		}
+		userCacheDir = filepath.Clean(dir)
+	})
+	return userCacheDir
+}
@@ -0,0 +1,184 @@
+package paths
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/user"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"syscall"
+)
+
+var (
+	// root is the directory every absolute system location is rebased onto
+	root = "/"
+
+	// home overrides the home directory; empty means $HOME rebased onto root
+	home string
+
+	// uid is the owner of the targeted home, or -1 for the current user
+	uid = -1
+)
+
+// SetRoot rebases every scan location and safety rule onto dir, e.g. a
+// mounted disk image or a fixture tree
+func SetRoot(dir string) error {
+	abs, err := filepath.Abs(dir)
+	if err != nil {
+		return fmt.Errorf("invalid root %q: %w", dir, err)
+	}
+	info, err := os.Stat(abs)
+	if err != nil {
+		return fmt.Errorf("invalid root: %w", err)
+	}
+	if !info.IsDir() {
+		return fmt.Errorf("invalid root: %s is not a directory", abs)
+	}
+	root = abs
+	return nil
+}
+
+// SetUser targets the home directory of another account. Outside the
+// default root the account is looked up as Users/<name> inside the root.
+func SetUser(name string) error {
+	if name == "" || strings.ContainsAny(name, "/") || name == "." || name == ".." {
+		return fmt.Errorf("invalid user name %q", name)
+	}
+
+	if root != "/" {
+		dir := filepath.Join(root, "Users", name)
+		info, err := os.Stat(dir)
+		if err != nil || !info.IsDir() {
+			return fmt.Errorf("no home directory for user %s under %s", name, root)
+		}
+		// Accounts of another system cannot be looked up, so the home's owner stands in
+		if stat, ok := info.Sys().(*syscall.Stat_t); ok {
+			uid = int(stat.Uid)
+		}
+		home = dir
+		return nil
+	}
+
+	u, err := user.Lookup(name)
+	if err != nil {
+		return fmt.Errorf("unknown user %s: %w", name, err)
+	}
+	if u.HomeDir == "" {
+		return fmt.Errorf("user %s has no home directory", name)
+	}
+	id, err := strconv.Atoi(u.Uid)
+	if err != nil {
+		return fmt.Errorf("user %s has no numeric uid: %s", name, u.Uid)
+	}
+	home = filepath.Clean(u.HomeDir)
+	uid = id
+	return nil
+}
+
+// ResolveHome works out whose home is cleaned when no user was given. A
+// regular user cleans $HOME. Under sudo $HOME may belong to root or to the
+// invoking user depending on the sudoers configuration, so it is ignored and
+// the account named by SUDO_USER is looked up instead. Running as root any
+// other way is refused, since there is no telling whose files are meant.
+func ResolveHome() error {
+	if os.Geteuid() != 0 {
+		return nil
+	}
+
+	invoking, err := sudoUser()
+	if err != nil {
+		return err
+	}
+	if invoking == nil {
+		return errors.New("running as root without sudo: use --user to choose whose home to clean")
+	}
+	return SetUser(invoking.Username)
+}
+
+// InvokingHome returns the home directory of the person running nuke on the
+// running system, which under sudo is the user who ran sudo. It is not
+// rebased and does not follow --user.
+func InvokingHome() string {
+	if os.Geteuid() == 0 {
+		if invoking, err := sudoUser(); err == nil && invoking != nil {
+			return filepath.Clean(invoking.HomeDir)
+		}
+	}
+	if env := os.Getenv("HOME"); env != "" {
+		return env
+	}
+	if current, err := user.Current(); err == nil {
+		return current.HomeDir
+	}
+	return ""
+}
+
+// sudoUser returns the account that ran sudo, or nil when nuke was not
+// started through sudo by a regular user
+func sudoUser() (*user.User, error) {
+	name := os.Getenv("SUDO_USER")
+	if name == "" || name == "root" {
+		return nil, nil
+	}
+
+	u, err := user.Lookup(name)
+	if err != nil {
+		return nil, fmt.Errorf("unknown SUDO_USER %s: %w", name, err)
+	}
+	if uid := os.Getenv("SUDO_UID"); uid != "" && uid != u.Uid {
+		return nil, fmt.Errorf("SUDO_USER %s does not match SUDO_UID %s: use --user to choose whose home to clean", name, uid)
+	}
+	if u.HomeDir == "" {
+		return nil, fmt.Errorf("user %s has no home directory", name)
+	}
+	return u, nil
+}
+
+// OwnerUID returns the uid of the user whose home is cleaned: the --user
+// account, the user who ran sudo or the current user
+func OwnerUID() int {
+	if uid >= 0 {
+		return uid
+	}
+	return os.Getuid()
+}
+
+// Root returns the directory system locations are rebased onto, "/" by default
+func Root() string {
+	return root
+}
+
+// Home returns the home directory being cleaned, already rebased onto the root
+func Home() string {
+	if home != "" {
+		return home
+	}
+	if env := os.Getenv("HOME"); env != "" {
+		return Rebase(env)
+	}
+	if current, err := user.Current(); err == nil && current.HomeDir != "" {
+		return Rebase(current.HomeDir)
+	}
+	return ""
+}
+
+// Rebase maps an absolute system location such as /Applications onto the root
+func Rebase(p string) string {
+	if root == "/" {
+		return p
+	}
+	return filepath.Join(root, p)
+}
+
+// InRoot reports whether an absolute path lies inside the root
+func InRoot(p string) bool {
+	return root == "/" || p == root || strings.HasPrefix(p, root+"/")
+}
+
+// isDefault reports whether the current user's own home is being cleaned on
+// the running system
+func isDefault() bool {
+	return root == "/" && home == ""
+}
@@ -0,0 +1,68 @@
+package policy
+
+import (
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// segments splits a cleaned absolute path into its components
+func segments(p string) []string {
+	p = strings.Trim(filepath.Clean(p), "/")
+	if p == "" {
+		return nil
+	}
+	return strings.Split(p, "/")
+}
+
+// matchSegments reports whether the pattern matches the path or one of its
+// ancestors, so that a rule for a directory covers everything inside it.
+// Each segment is a path.Match pattern and ** matches any number of segments.
+func matchSegments(pattern, p []string) bool {
+	if len(pattern) == 0 {
+		return true
+	}
+	if pattern[0] == "**" {
+		for i := 0; i <= len(p); i++ {
+			if matchSegments(pattern[1:], p[i:]) {
+				return true
+			}
+		}
+		return false
+	}
+	if len(p) == 0 {
+		return false
+	}
+	if ok, _ := path.Match(pattern[0], p[0]); !ok {
+		return false
+	}
+	return matchSegments(pattern[1:], p[1:])
+}
+
+// containsMatch reports whether the path is a strict ancestor of something
+// the pattern matches, i.e. deleting it would delete a matching path too
+func containsMatch(pattern, p []string) bool {
+	if len(p) == 0 {
+		return len(pattern) > 0
+	}
+	if len(pattern) == 0 {
+		return false
+	}
+	if pattern[0] == "**" {
+		return true
+	}
+	if ok, _ := path.Match(pattern[0], p[0]); !ok {
+		return false
+	}
+	return containsMatch(pattern[1:], p[1:])
+}
+
+// validGlob checks that every segment of a pattern is well formed
+func validGlob(pattern string) bool {
+	for _, segment := range segments(pattern) {
+		if _, err := path.Match(segment, ""); err != nil {
+			return false
+		}
+	}
+	return true
+}
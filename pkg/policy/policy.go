@@ -0,0 +1,348 @@
+package policy
+
+import (
+	"fmt"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/alexintosh/gocleaner/pkg/paths"
+)
+
+// Action is what a rule decides for the paths it matches
+type Action string
+
+const (
+	Allow Action = "allow"
+	Deny  Action = "deny"
+)
+
+// Scope limits a rule to paths inside (user) or outside (system) the home directory
+type Scope string
+
+const (
+	ScopeAny    Scope = ""
+	ScopeUser   Scope = "user"
+	ScopeSystem Scope = "system"
+)
+
+// Rule allows or denies deleting the paths or bundle IDs it matches. Path
+// patterns may start with ~ for the home directory and match the path they
+// name and everything inside it.
+type Rule struct {
+	Action   Action
+	Scope    Scope
+	Pattern  string
+	BundleID string
+
+	// Source tells where the rule was defined, e.g. "built-in" or a config file line
+	Source string
+}
+
+func (r Rule) String() string {
+	parts := []string{string(r.Action)}
+	if r.Scope != ScopeAny {
+		parts = append(parts, string(r.Scope))
+	}
+	if r.BundleID != "" {
+		parts = append(parts, "bundle:"+r.BundleID)
+	} else {
+		parts = append(parts, r.Pattern)
+	}
+	return strings.Join(parts, " ")
+}
+
+// ParseRule parses a rule written as "<allow|deny> [user|system] <pattern>",
+// where the pattern is a path glob or "bundle:" followed by a bundle ID glob
+func ParseRule(text, source string) (Rule, error) {
+	fields := strings.Fields(text)
+	if len(fields) < 2 {
+		return Rule{}, fmt.Errorf("rule %q must be \"<allow|deny> [user|system] <pattern>\"", text)
+	}
+
+	rule := Rule{Action: Action(fields[0]), Source: source}
+	if rule.Action != Allow && rule.Action != Deny {
+		return Rule{}, fmt.Errorf("rule %q must start with allow or deny", text)
+	}
+
+	rest := strings.TrimSpace(strings.TrimPrefix(strings.TrimSpace(text), fields[0]))
+	if fields[1] == string(ScopeUser) || fields[1] == string(ScopeSystem) {
+		rule.Scope = Scope(fields[1])
+		rest = strings.TrimSpace(strings.TrimPrefix(rest, fields[1]))
+	}
+	if rest == "" {
+		return Rule{}, fmt.Errorf("rule %q has no pattern", text)
+	}
+
+	if id, ok := strings.CutPrefix(rest, "bundle:"); ok {
+		if _, err := path.Match(id, ""); err != nil || id == "" {
+			return Rule{}, fmt.Errorf("rule %q has an invalid bundle ID pattern", text)
+		}
+		rule.BundleID = id
+		return rule, nil
+	}
+
+	if !strings.HasPrefix(rest, "/") && !strings.HasPrefix(rest, "~") {
+		return Rule{}, fmt.Errorf("rule %q: path patterns must be absolute or start with ~/", text)
+	}
+	if !validGlob(rest) {
+		return Rule{}, fmt.Errorf("rule %q has an invalid path pattern", text)
+	}
+	rule.Pattern = rest
+	return rule, nil
+}
+
+// Subject is something the policy is asked about: a path and the bundle IDs
+// it is known to belong to
+type Subject struct {
+	Path      string
+	BundleIDs []string
+}
+
+// Verdict is the outcome of evaluating a subject
+type Verdict struct {
+	Allowed bool
+
+	// Rule is the rule that decided, nil when no rule matched
+	Rule *Rule
+
+	// Reason explains the verdict in words
+	Reason string
+}
+
+// Critical system paths that should never be touched
+var criticalPaths = []string{
+	"/System",
+	"/bin",
+	"/sbin",
+	"/usr/bin",
+	"/usr/sbin",
+	"/usr/local/bin",
+	"/usr/local/sbin",
+	"/etc",
+	"/var",
+	"/private/etc",
+}
+
+// System locations denied after the built-in allows, so nothing outside the
+// app data folders, the applications and the per-user cache is deleted there
+var systemLocations = []string{
+	"/usr",
+	"/private/var",
+}
+
+// SystemLocations are the folders configured scan and application roots may
+// not contain, in addition to the home directory being cleaned
+var SystemLocations = append(append([]string{"/Applications", "/Library", "/Users"}, criticalPaths...), systemLocations...)
+
+// Directories in the home folder that we should never remove files from
+var unsafeDirs = []string{
+	"Documents",
+	"Downloads",
+	"Desktop",
+	"Pictures",
+	"Music",
+	"Movies",
+}
+
+// Directories in the home folder that are safe to remove app-related files from
+var safeDirs = []string{
+	"Library/Application Support",
+	"Library/Preferences",
+	"Library/Caches",
+	"Library/Logs",
+	"Library/Containers",
+	"Library/Saved Application State",
+}
+
+var (
+	// systemRules come from the administrator's policy files and are
+	// evaluated before every other rule, so nothing can override them
+	systemRules []Rule
+
+	// userRules are added from the configuration file and are evaluated
+	// after the built-in deny rules but before the built-in allow rules
+	userRules []Rule
+
+	// matchedPaths maps the items the finder matched in configured scan and
+	// application roots to the root they were found in. Only these items are
+	// allowed there, never the rest of the root.
+	matchedPaths = map[string]string{}
+)
+
+// AddSystemRules appends rules that take precedence over all other rules
+func AddSystemRules(rules ...Rule) {
+	systemRules = append(systemRules, rules...)
+}
+
+// AddRules appends user rules
+func AddRules(rules ...Rule) {
+	userRules = append(userRules, rules...)
+}
+
+// AllowMatch allows deleting an item the finder matched in a configured root,
+// unless a deny rule or a built-in system location says otherwise
+func AllowMatch(p, root string) {
+	matchedPaths[filepath.Clean(p)] = filepath.Clean(root)
+}
+
+// Rules returns every rule in evaluation order. The first matching rule
+// decides; when none matches the path is denied. Built-in system locations
+// are rebased onto the root and ~ is the home directory being cleaned.
+func Rules() []Rule {
+	rules := append([]Rule{}, systemRules...)
+
+	for _, p := range criticalPaths {
+		rules = append(rules, Rule{Action: Deny, Pattern: paths.Rebase(p), Source: "built-in: critical system path"})
+	}
+	for _, dir := range unsafeDirs {
+		rules = append(rules, Rule{Action: Deny, Scope: ScopeUser, Pattern: "~/" + dir, Source: "built-in: user documents"})
+	}
+
+	rules = append(rules, userRules...)
+
+	for _, dir := range safeDirs {
+		rules = append(rules, Rule{Action: Allow, Scope: ScopeUser, Pattern: "~/" + dir + "/*", Source: "built-in: app data"})
+	}
+	rules = append(rules,
+		Rule{Action: Allow, Pattern: paths.Rebase("/Applications/*"), Source: "built-in: applications"},
+		Rule{Action: Allow, Scope: ScopeUser, Pattern: "~/Applications/*", Source: "built-in: applications"},
+	)
+	if cacheDir := paths.DarwinUserCacheDir(); cacheDir != "" {
+		rules = append(rules, Rule{Action: Allow, Pattern: cacheDir + "/*", Source: "built-in: per-user cache"})
+	}
+
+	for _, p := range systemLocations {
+		rules = append(rules, Rule{Action: Deny, Pattern: paths.Rebase(p), Source: "built-in: system location"})
+	}
+	rules = append(rules, Rule{Action: Deny, Scope: ScopeSystem, Pattern: paths.Rebase("/Users/*"), Source: "built-in: other users"})
+
+	matched := make([]string, 0, len(matchedPaths))
+	for p := range matchedPaths {
+		matched = append(matched, p)
+	}
+	sort.Strings(matched)
+	for _, p := range matched {
+		rules = append(rules, Rule{Action: Allow, Pattern: escapeGlob(p), Source: "matched in configured root " + matchedPaths[p]})
+	}
+
+	// Configured roots inside /Library keep their matches, nothing else there is deleted
+	rules = append(rules, Rule{Action: Deny, Pattern: paths.Rebase("/Library"), Source: "built-in: system location"})
+
+	return rules
+}
+
+// Evaluate decides whether the subject may be deleted
+func Evaluate(s Subject) Verdict {
+	return evaluate(s, Rules())
+}
+
+// SystemVerdict evaluates the subject against the system rules only. It
+// reports whether one of them matched, which means it must be refused.
+func SystemVerdict(s Subject) (Verdict, bool) {
+	verdict := evaluate(s, systemRules)
+	return verdict, verdict.Rule != nil
+}
+
+// escapeGlob turns a path into a pattern matching only that path
+func escapeGlob(p string) string {
+	var b strings.Builder
+	for _, r := range p {
+		if strings.ContainsRune(`*?[\`, r) {
+			b.WriteRune('\\')
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+// evaluate returns the verdict of the first rule matching the subject
+func evaluate(s Subject, rules []Rule) Verdict {
+	home := paths.Home()
+	p := filepath.Clean(s.Path)
+	if !filepath.IsAbs(p) {
+		return Verdict{Reason: "relative paths are never deleted"}
+	}
+	if !paths.InRoot(p) {
+		return Verdict{Reason: fmt.Sprintf("outside the root %s", paths.Root())}
+	}
+
+	for i := range rules {
+		rule := &rules[i]
+		if matched, how := rule.matches(s, p, home); matched {
+			verdict := Verdict{Allowed: rule.Action == Allow, Rule: rule}
+			verdict.Reason = fmt.Sprintf("%s by rule %q (%s)", how, rule.String(), rule.Source)
+			return verdict
+		}
+	}
+
+	return Verdict{Reason: "no rule allows deleting this path"}
+}
+
+// matches reports whether the rule applies to the subject and how
+func (r *Rule) matches(s Subject, p, home string) (bool, string) {
+	inHome := home != "" && (p == home || strings.HasPrefix(p, home+"/"))
+	if (r.Scope == ScopeUser && !inHome) || (r.Scope == ScopeSystem && inHome) {
+		return false, ""
+	}
+
+	verb := "allowed"
+	if r.Action == Deny {
+		verb = "denied"
+	}
+
+	if r.BundleID != "" {
+		for _, id := range s.BundleIDs {
+			if ok, _ := path.Match(r.BundleID, id); ok {
+				return true, fmt.Sprintf("%s for bundle ID %s", verb, id)
+			}
+		}
+		return false, ""
+	}
+
+	pattern := segments(expandHome(r.Pattern, home))
+	if matchSegments(pattern, segments(p)) {
+		return true, verb
+	}
+
+	// Deleting a directory that holds a denied path would delete it too
+	if r.Action == Deny && containsMatch(pattern, segments(p)) {
+		return true, "denied because it contains a path matched"
+	}
+	return false, ""
+}
+
+// expandHome replaces a leading ~ with the home directory
+func expandHome(pattern, home string) string {
+	if pattern == "~" {
+		return home
+	}
+	if rest, ok := strings.CutPrefix(pattern, "~/"); ok {
+		return filepath.Join(home, rest)
+	}
+	return pattern
+}
+
+// bundleIDExtensions are stripped from file names to find the bundle ID they are named after
+var bundleIDExtensions = []string{".plist", ".savedState", ".binarycookies", ".log", ".app"}
+
+// SubjectFor builds a subject for a path, deriving bundle IDs from its file
+// name (e.g. com.example.App.plist) when it looks like one
+func SubjectFor(p string, knownIDs ...string) Subject {
+	bundleIDs := []string{}
+	for _, id := range knownIDs {
+		if id != "" {
+			bundleIDs = append(bundleIDs, id)
+		}
+	}
+
+	name := filepath.Base(p)
+	for _, ext := range bundleIDExtensions {
+		name = strings.TrimSuffix(name, ext)
+	}
+	if strings.Count(name, ".") >= 2 && !strings.ContainsAny(name, " /") {
+		bundleIDs = append(bundleIDs, name)
+	}
+	return Subject{Path: p, BundleIDs: bundleIDs}
+}
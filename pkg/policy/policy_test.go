@@ -0,0 +1,294 @@
+package policy
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/alexintosh/gocleaner/pkg/paths"
+)
+
+// withRules replaces the configured rules for the duration of a test and
+// makes /Users/alice the home being cleaned
+func withRules(t *testing.T, system, user []string) {
+	t.Helper()
+	t.Setenv("HOME", "/Users/alice")
+
+	savedSystem, savedUser, savedMatched := systemRules, userRules, matchedPaths
+	t.Cleanup(func() { systemRules, userRules, matchedPaths = savedSystem, savedUser, savedMatched })
+	systemRules, userRules, matchedPaths = nil, nil, map[string]string{}
+
+	for _, text := range system {
+		rule, err := ParseRule(text, "test system policy")
+		if err != nil {
+			t.Fatal(err)
+		}
+		AddSystemRules(rule)
+	}
+	for _, text := range user {
+		rule, err := ParseRule(text, "test config")
+		if err != nil {
+			t.Fatal(err)
+		}
+		AddRules(rule)
+	}
+}
+
+func TestMatchSegments(t *testing.T) {
+	tests := []struct {
+		pattern string
+		path    string
+		want    bool
+	}{
+		{"/Applications/*", "/Applications/Foo.app", true},
+		{"/Applications/*", "/Applications/Foo.app/Contents/Info.plist", true},
+		{"/Applications/*", "/Applications", false},
+		{"/Applications/*.app", "/Applications/Utilities", false},
+		{"/Users/alice/Library", "/Users/alice/Library", true},
+		{"/Users/alice/Library", "/Users/alice/Lib", false},
+		{"/Users/*/Library/Caches/com.*", "/Users/bob/Library/Caches/com.example.App", true},
+		{"/**/com.secret.*", "/Users/alice/Library/Caches/com.secret.x", true},
+		{"/Users/alice/**/Caches", "/Users/alice/Caches", true},
+		{"/Users/alice/**/Caches", "/Users/alice/Library/Caches/sub", true},
+		{"/Users/alice/**/Caches", "/Users/alice/Library/Logs", false},
+		{"/Users/alice/**", "/Users/alice", true},
+		{"/**", "/", true},
+	}
+
+	for _, tt := range tests {
+		if got := matchSegments(segments(tt.pattern), segments(tt.path)); got != tt.want {
+			t.Errorf("matchSegments(%q, %q) = %v, want %v", tt.pattern, tt.path, got, tt.want)
+		}
+	}
+}
+
+func TestContainsMatch(t *testing.T) {
+	tests := []struct {
+		pattern string
+		path    string
+		want    bool
+	}{
+		{"/Users/alice/Documents", "/Users/alice", true},
+		{"/Users/alice/Documents", "/Users", true},
+		{"/Users/alice/Documents", "/", true},
+		{"/Users/alice/Documents", "/Users/alice/Documents", false},
+		{"/Users/alice/Documents", "/Users/alice/Documents/report.pdf", false},
+		{"/Users/alice/Documents", "/Users/alice/Library", false},
+		{"/Users/*/Documents", "/Users/bob", true},
+		{"/Users/alice/**/secret", "/Users/alice/Library", true},
+		{"/Users/alice/**/secret", "/Users/bob", false},
+	}
+
+	for _, tt := range tests {
+		if got := containsMatch(segments(tt.pattern), segments(tt.path)); got != tt.want {
+			t.Errorf("containsMatch(%q, %q) = %v, want %v", tt.pattern, tt.path, got, tt.want)
+		}
+	}
+}
+
+func TestEvaluate(t *testing.T) {
+	tests := []struct {
+		name    string
+		system  []string
+		user    []string
+		path    string
+		allowed bool
+		reason  string
+	}{
+		{name: "app data", path: "/Users/alice/Library/Caches/com.example.App", allowed: true, reason: "built-in: app data"},
+		{name: "app data folder itself", path: "/Users/alice/Library/Caches", reason: "no rule allows"},
+		{name: "application", path: "/Applications/Foo.app", allowed: true, reason: "built-in: applications"},
+		{name: "user application", path: "/Users/alice/Applications/Foo.app", allowed: true, reason: "built-in: applications"},
+		{name: "documents", path: "/Users/alice/Documents/report.pdf", reason: "built-in: user documents"},
+		{name: "home contains documents", path: "/Users/alice", reason: "denied because it contains a path matched"},
+		{name: "critical path", path: "/System/Library/Foo", reason: "built-in: critical system path"},
+		{name: "root folder", path: "/", reason: "denied because it contains a path matched"},
+		{name: "default deny", path: "/opt/tools/foo", reason: "no rule allows"},
+		{name: "usr", path: "/usr/local/share/foo", reason: "built-in: system location"},
+		{name: "private var", path: "/private/var/db/foo", reason: "built-in: system location"},
+		{name: "system library", path: "/Library/Caches/com.example.App", reason: "built-in: system location"},
+		{name: "other user", path: "/Users/bob/Library/Caches/com.example.App", reason: "built-in: other users"},
+		{name: "users folder", path: "/Users", reason: "denied because it contains a path matched"},
+		{name: "relative", path: "Library/Caches/foo", reason: "relative paths are never deleted"},
+		{name: "unclean path is cleaned", path: "/Users/alice/Library/Caches/../../Documents/x", reason: "built-in: user documents"},
+
+		{name: "user deny before built-in allow", user: []string{"deny ~/Library/Caches/com.keep.*"},
+			path: "/Users/alice/Library/Caches/com.keep.App", reason: "test config"},
+		{name: "built-in deny before user allow", user: []string{"allow ~/Documents/*"},
+			path: "/Users/alice/Documents/tmp", reason: "built-in: user documents"},
+		{name: "user allow", user: []string{"allow /opt/tools/*"},
+			path: "/opt/tools/foo", allowed: true, reason: "test config"},
+		{name: "first user rule wins", user: []string{"allow /opt/tools/*", "deny /opt/tools/foo"},
+			path: "/opt/tools/foo", allowed: true, reason: "test config"},
+		{name: "system before user", system: []string{"deny ~/Library/Caches/com.corp.*"}, user: []string{"allow ~/Library/Caches/com.corp.*"},
+			path: "/Users/alice/Library/Caches/com.corp.Agent", reason: "test system policy"},
+		{name: "system before built-in deny", system: []string{"allow /System/Library/Foo"},
+			path: "/System/Library/Foo", allowed: true, reason: "test system policy"},
+
+		{name: "user scope inside home", user: []string{"allow user ~/Stuff/*"},
+			path: "/Users/alice/Stuff/x", allowed: true, reason: "test config"},
+		{name: "user scope outside home", user: []string{"allow user /opt/*"},
+			path: "/opt/x", reason: "no rule allows"},
+		{name: "system scope outside home", user: []string{"allow system /opt/*"},
+			path: "/opt/x", allowed: true, reason: "test config"},
+		{name: "system scope inside home", user: []string{"allow system /Users/alice/Stuff/*"},
+			path: "/Users/alice/Stuff/x", reason: "no rule allows"},
+		{name: "tilde is the home", user: []string{"deny ~/Library/Caches/keep"},
+			path: "/Users/alice/Library/Caches/keep", reason: "test config"},
+
+		{name: "double star", user: []string{"deny ~/Library/**/com.secret.*"},
+			path: "/Users/alice/Library/Caches/sub/com.secret.x", reason: "test config"},
+		{name: "double star contained", user: []string{"deny ~/Library/**/com.secret.*"},
+			path: "/Users/alice/Library/Caches/sub", reason: "denied because it contains a path matched"},
+		{name: "bundle rule from file name", user: []string{"deny bundle:com.keep.*"},
+			path: "/Users/alice/Library/Preferences/com.keep.App.plist", reason: "denied for bundle ID com.keep.App"},
+		{name: "bundle rule not matching", user: []string{"deny bundle:com.keep.*"},
+			path: "/Users/alice/Library/Preferences/com.other.App.plist", allowed: true, reason: "built-in: app data"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			withRules(t, tt.system, tt.user)
+			verdict := Evaluate(SubjectFor(tt.path))
+			if verdict.Allowed != tt.allowed || !strings.Contains(verdict.Reason, tt.reason) {
+				t.Errorf("Evaluate(%q) = %v %q, want %v with a reason containing %q", tt.path, verdict.Allowed, verdict.Reason, tt.allowed, tt.reason)
+			}
+		})
+	}
+}
+
+func TestAllowMatch(t *testing.T) {
+	withRules(t, nil, []string{"deny /opt/cache/keep"})
+	AllowMatch("/opt/cache/com.example.App", "/opt/cache")
+	AllowMatch("/opt/cache/Foo [1]", "/opt/cache")
+	AllowMatch("/opt/cache/keep", "/opt/cache")
+	AllowMatch("/Library/Caches/com.example.App", "/Library/Caches")
+	AllowMatch("/usr/local/share/com.example.App", "/usr/local/share")
+
+	tests := []struct {
+		path    string
+		allowed bool
+		reason  string
+	}{
+		{"/opt/cache/com.example.App", true, "matched in configured root /opt/cache"},
+		{"/opt/cache/com.example.App/sub", true, "matched in configured root"},
+		{"/opt/cache/Foo [1]", true, "matched in configured root"},
+		{"/opt/cache/Foo 1", false, "no rule allows"},
+		{"/opt/cache/other", false, "no rule allows"},
+		{"/opt/cache/keep", false, "test config"},
+		{"/Library/Caches/com.example.App", true, "matched in configured root /Library/Caches"},
+		{"/Library/Caches/other", false, "built-in: system location"},
+		{"/usr/local/share/com.example.App", false, "built-in: system location"},
+	}
+	for _, tt := range tests {
+		verdict := Evaluate(SubjectFor(tt.path))
+		if verdict.Allowed != tt.allowed || !strings.Contains(verdict.Reason, tt.reason) {
+			t.Errorf("Evaluate(%q) = %v %q, want %v with a reason containing %q", tt.path, verdict.Allowed, verdict.Reason, tt.allowed, tt.reason)
+		}
+	}
+}
+
+func TestEvaluateKnownBundleID(t *testing.T) {
+	withRules(t, []string{"deny bundle:com.apple.*"}, nil)
+
+	verdict := Evaluate(SubjectFor("/Applications/Safari.app", "com.apple.Safari"))
+	if verdict.Allowed || !strings.Contains(verdict.Reason, "com.apple.Safari") {
+		t.Errorf("Safari.app = %v %q, want denied for its bundle ID", verdict.Allowed, verdict.Reason)
+	}
+	if verdict := Evaluate(SubjectFor("/Applications/Foo.app", "com.example.Foo")); !verdict.Allowed {
+		t.Errorf("Foo.app = denied %q, want allowed", verdict.Reason)
+	}
+}
+
+func TestEvaluateRoot(t *testing.T) {
+	root, err := filepath.EvalSymlinks(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.MkdirAll(filepath.Join(root, "Applications"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := paths.SetRoot(root); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { paths.SetRoot("/") })
+	withRules(t, nil, nil)
+
+	tests := []struct {
+		path    string
+		allowed bool
+		reason  string
+	}{
+		{"/Applications/Foo.app", false, "outside the root"},
+		{filepath.Join(root, "Applications", "Foo.app"), true, "built-in: applications"},
+		{filepath.Join(root, "Users", "alice", "Library", "Caches", "foo"), true, "built-in: app data"},
+		{filepath.Join(root, "System", "Library"), false, "built-in: critical system path"},
+		{root, false, "denied because it contains a path matched"},
+	}
+	for _, tt := range tests {
+		verdict := Evaluate(SubjectFor(tt.path))
+		if verdict.Allowed != tt.allowed || !strings.Contains(verdict.Reason, tt.reason) {
+			t.Errorf("Evaluate(%q) = %v %q, want %v with a reason containing %q", tt.path, verdict.Allowed, verdict.Reason, tt.allowed, tt.reason)
+		}
+	}
+}
+
+func TestSubjectFor(t *testing.T) {
+	tests := []struct {
+		path  string
+		known []string
+		want  []string
+	}{
+		{"/Users/alice/Library/Preferences/com.example.App.plist", nil, []string{"com.example.App"}},
+		{"/Users/alice/Library/Saved Application State/com.example.App.savedState", nil, []string{"com.example.App"}},
+		{"/Users/alice/Library/Cookies/com.example.App.binarycookies", nil, []string{"com.example.App"}},
+		{"/Users/alice/Library/Logs/com.example.App.log", nil, []string{"com.example.App"}},
+		{"/Users/alice/Library/Caches/com.example.App", nil, []string{"com.example.App"}},
+		{"/Applications/Foo.app", []string{"com.example.Foo"}, []string{"com.example.Foo"}},
+		{"/Applications/Foo.app", []string{""}, []string{}},
+		{"/Users/alice/Library/Caches/Foo", nil, []string{}},
+		{"/Users/alice/Library/Preferences/Foo.plist", nil, []string{}},
+		{"/Users/alice/Library/Caches/Foo Bar.v1.2", nil, []string{}},
+	}
+
+	for _, tt := range tests {
+		if got := SubjectFor(tt.path, tt.known...).BundleIDs; !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("SubjectFor(%q, %q) = %q, want %q", tt.path, tt.known, got, tt.want)
+		}
+	}
+}
+
+func TestParseRule(t *testing.T) {
+	tests := []struct {
+		text    string
+		want    Rule
+		wantErr string
+	}{
+		{text: "allow ~/Library/Caches/*", want: Rule{Action: Allow, Pattern: "~/Library/Caches/*"}},
+		{text: "deny user ~/Library/Group Containers/*", want: Rule{Action: Deny, Scope: ScopeUser, Pattern: "~/Library/Group Containers/*"}},
+		{text: "deny system /Library/**", want: Rule{Action: Deny, Scope: ScopeSystem, Pattern: "/Library/**"}},
+		{text: "deny bundle:com.apple.*", want: Rule{Action: Deny, BundleID: "com.apple.*"}},
+		{text: "allow", wantErr: "must be"},
+		{text: "keep /Applications/*", wantErr: "must start with allow or deny"},
+		{text: "allow user", wantErr: "has no pattern"},
+		{text: "allow Library/Caches/*", wantErr: "must be absolute"},
+		{text: "allow /Library/[", wantErr: "invalid path pattern"},
+		{text: "deny bundle:", wantErr: "invalid bundle ID pattern"},
+	}
+
+	for _, tt := range tests {
+		got, err := ParseRule(tt.text, "test")
+		if tt.wantErr != "" {
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("ParseRule(%q) error = %v, want one containing %q", tt.text, err, tt.wantErr)
+			}
+			continue
+		}
+		tt.want.Source = "test"
+		if err != nil || got != tt.want {
+			t.Errorf("ParseRule(%q) = %+v, %v, want %+v", tt.text, got, err, tt.want)
+		}
+	}
+}
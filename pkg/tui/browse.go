@@ -0,0 +1,251 @@
+package tui
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/alexintosh/gocleaner/pkg/finder"
+	"github.com/alexintosh/gocleaner/pkg/policy"
+	"github.com/charmbracelet/bubbles/key"
+	"github.com/charmbracelet/bubbles/list"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// browser lets files the scan missed be added to the selection. It starts
+// with the scan locations and never leaves them.
+type browser struct {
+	locations []finder.ScanLocation
+
+	// location is the scan location being browsed; nil lists the locations
+	location *finder.ScanLocation
+	dir      string
+	entries  list.Model
+
+	// message tells what became of the last item added
+	message string
+}
+
+// browseItem is a scan location, or an entry of the directory being browsed
+// along with what the policy says about deleting it
+type browseItem struct {
+	path     string
+	name     string
+	dir      bool
+	location *finder.ScanLocation
+	verdict  policy.Verdict
+	listed   bool
+}
+
+func (i browseItem) Title() string {
+	name := i.name
+	if i.dir {
+		name += "/"
+	}
+	if i.listed {
+		name += "  (listed)"
+	}
+	return name
+}
+
+// Description shows the category of a location, or the verdict on an entry
+func (i browseItem) Description() string {
+	if i.location != nil {
+		return "    " + string(i.location.Category)
+	}
+	if i.verdict.Allowed {
+		return "    may be deleted: " + i.verdict.Reason
+	}
+	return "    protected: " + i.verdict.Reason
+}
+
+func (i browseItem) FilterValue() string {
+	return i.name
+}
+
+// includedMsg carries an item added from the browser once it has been
+// checked and measured
+type includedMsg struct {
+	match   finder.Match
+	size    int64
+	verdict policy.Verdict
+}
+
+// openBrowser switches to the browser, listing the scan locations that exist
+func (m Model) openBrowser() (tea.Model, tea.Cmd) {
+	b := &browser{}
+	for _, location := range finder.ScanLocations() {
+		if info, err := os.Stat(location.Path); err == nil && info.IsDir() {
+			b.locations = append(b.locations, location)
+		}
+	}
+
+	b.entries = list.New([]list.Item{}, list.NewDefaultDelegate(), 0, 0)
+	b.entries.SetFilteringEnabled(false)
+	b.entries.SetShowStatusBar(false)
+	b.entries.Styles.Title = titleStyle
+	b.entries.SetShowHelp(false)
+	b.entries.KeyMap.CursorUp = m.keys.Up
+	b.entries.KeyMap.CursorDown = m.keys.Down
+	b.entries.KeyMap.PrevPage = m.keys.PrevPage
+	b.entries.KeyMap.NextPage = m.keys.NextPage
+	b.entries.KeyMap.Quit = m.keys.Quit
+	b.entries.SetSize(m.fileList.Width(), m.height-10)
+
+	m.browser = b
+	m.state = stateBrowse
+	m.showLocations()
+	return m, nil
+}
+
+// showLocations lists the scan locations
+func (m *Model) showLocations() {
+	b := m.browser
+	b.location, b.dir = nil, ""
+	b.entries.Title = "Scan locations"
+
+	items := []list.Item{}
+	for i := range b.locations {
+		location := &b.locations[i]
+		items = append(items, browseItem{path: location.Path, name: shortenHome(location.Path), dir: true, location: location})
+	}
+	b.entries.SetItems(items)
+	b.entries.Select(0)
+}
+
+// showDir lists a directory inside the current scan location with the
+// policy's verdict on every entry
+func (m *Model) showDir(dir string) {
+	b := m.browser
+	b.dir = dir
+	b.entries.Title = shortenHome(dir)
+
+	items := []list.Item{}
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		b.message = errorStyle.Render(err.Error())
+	}
+	for _, entry := range entries {
+		path := filepath.Join(dir, entry.Name())
+		_, listed := m.tree.selected[path]
+		items = append(items, browseItem{
+			path:    path,
+			name:    entry.Name(),
+			dir:     entry.IsDir(),
+			verdict: m.appCleaner.Verdict(path),
+			listed:  listed,
+		})
+	}
+	b.entries.SetItems(items)
+	b.entries.Select(0)
+}
+
+// updateBrowse handles keys in the browser
+func (m Model) updateBrowse(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	b := m.browser
+	item, _ := b.entries.SelectedItem().(browseItem)
+
+	switch {
+	case msg.String() == "ctrl+c":
+		return m, tea.Quit
+
+	case key.Matches(msg, m.keys.ClearFilter, m.keys.Quit):
+		m.state = stateSelectFiles
+		m.browser = nil
+		return m, nil
+
+	case key.Matches(msg, m.keys.Expand, m.keys.Confirm):
+		if item.location != nil {
+			b.location = item.location
+		}
+		if item.dir {
+			m.showDir(item.path)
+		}
+		return m, nil
+
+	case key.Matches(msg, m.keys.Collapse) || msg.String() == "backspace":
+		switch {
+		case b.location == nil:
+		case b.dir == b.location.Path:
+			m.showLocations()
+		default:
+			parent := b.dir
+			m.showDir(filepath.Dir(b.dir))
+			for i, entry := range b.entries.Items() {
+				if entry.(browseItem).path == parent {
+					b.entries.Select(i)
+					break
+				}
+			}
+		}
+		return m, nil
+
+	case key.Matches(msg, m.keys.Toggle):
+		// Whole scan locations are never deleted
+		if item.path == "" || item.location != nil {
+			return m, nil
+		}
+		b.message = "Checking " + item.name + "..."
+		return m, m.includeItem(item.path)
+	}
+
+	var cmd tea.Cmd
+	b.entries, cmd = b.entries.Update(msg)
+	return m, cmd
+}
+
+// includeItem asks the policy about an item and measures it before it is
+// added to the selection
+func (m Model) includeItem(path string) tea.Cmd {
+	appCleaner := m.appCleaner
+	return func() tea.Msg {
+		msg := includedMsg{match: finder.IncludedMatch(path), verdict: appCleaner.Verdict(path)}
+		if msg.verdict.Allowed {
+			msg.size = finder.PathSize(path)
+		}
+		return msg
+	}
+}
+
+// included adds a checked item to the tree unless the policy refused it
+func (m Model) included(msg includedMsg) (tea.Model, tea.Cmd) {
+	var message string
+	path := msg.match.Path
+	if _, ok := m.tree.selected[path]; ok {
+		m.tree.selected[path] = true
+		m.refreshList()
+		message = statusMessageStyle.Render(filepath.Base(path) + " is already listed and is now selected")
+	} else if !msg.verdict.Allowed {
+		message = errorStyle.Render(fmt.Sprintf("Not added, %s is protected: %s", filepath.Base(path), msg.verdict.Reason))
+	} else {
+		m.tree.add(m.appNames[0], msg.match, msg.size)
+		m.files = append(m.files, path)
+		m.refreshList()
+		message = statusMessageStyle.Render(fmt.Sprintf("Added %s to %s, it may be deleted: %s", filepath.Base(path), msg.match.Category, msg.verdict.Reason))
+	}
+
+	// The browser may have been closed while the item was measured
+	if m.browser != nil {
+		m.browser.message = message
+		if m.browser.location != nil && filepath.Dir(path) == m.browser.dir {
+			index := m.browser.entries.Index()
+			m.showDir(m.browser.dir)
+			m.browser.entries.Select(index)
+		}
+	}
+	return m, nil
+}
+
+// browseView renders the browser
+func (m Model) browseView() string {
+	var s strings.Builder
+	s.WriteString(titleStyle.Render("Add files to the selection") + "\n\n")
+	s.WriteString(fileListStyle.Render(m.browser.entries.View()) + "\n")
+	if m.browser.message != "" {
+		s.WriteString(m.browser.message + "\n")
+	}
+	s.WriteString(fmt.Sprintf("\n%s to open a folder, %s to go back, %s to add the highlighted item, %s to return to the selection\n",
+		m.keys.Expand.Help().Key, m.keys.Collapse.Help().Key, m.keys.Toggle.Help().Key, m.keys.ClearFilter.Help().Key))
+	return s.String()
+}
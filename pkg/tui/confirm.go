@@ -0,0 +1,171 @@
+package tui
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/alexintosh/gocleaner/pkg/cleaner"
+	"github.com/alexintosh/gocleaner/pkg/finder"
+	"github.com/alexintosh/gocleaner/pkg/units"
+	"github.com/charmbracelet/bubbles/key"
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// Deletions above either limit must be confirmed by typing instead of y
+const (
+	largeDeletionBytes = 1 << 30
+	largeDeletionItems = 100
+)
+
+// confirmation summarizes the selected files before anything is deleted
+type confirmation struct {
+	count         int
+	size          int64
+	categories    map[finder.Category]int
+	lowConfidence []string
+	privileged    []string
+
+	// sizes holds the size of every selected item to track deleted bytes
+	sizes map[string]int64
+
+	// word must be typed to confirm a large deletion; empty means y is enough
+	word  string
+	input textinput.Model
+}
+
+// confirmReadyMsg carries the confirmation once sizes have been computed
+type confirmReadyMsg struct {
+	confirm *confirmation
+}
+
+// prepareConfirm sizes the selected files, reusing the sizes measured after
+// the scan, and collects what deserves a
+// second look before they are deleted
+func (m Model) prepareConfirm(matches []finder.Match) tea.Cmd {
+	word := m.appLabel()
+	if len(m.appNames) > 1 {
+		word = "delete"
+	}
+
+	known := map[string]int64{}
+	if m.tree != nil {
+		known = m.tree.sizes
+	}
+
+	return func() tea.Msg {
+		c := &confirmation{count: len(matches), categories: map[finder.Category]int{}, sizes: map[string]int64{}}
+		for _, match := range matches {
+			size, ok := known[match.Path]
+			if !ok {
+				size = finder.PathSize(match.Path)
+			}
+			c.sizes[match.Path] = size
+			c.size += size
+			c.categories[match.Category]++
+			if match.Confidence == finder.ConfidenceLow {
+				c.lowConfidence = append(c.lowConfidence, match.Path)
+			}
+			if cleaner.NeedsPrivilege(match.Path) {
+				c.privileged = append(c.privileged, match.Path)
+			}
+		}
+
+		if c.size >= largeDeletionBytes || c.count >= largeDeletionItems {
+			c.word = word
+			c.input = textinput.New()
+			c.input.Placeholder = word
+			c.input.Focus()
+		}
+		return confirmReadyMsg{c}
+	}
+}
+
+// updateConfirm handles keys on the confirmation screen
+func (m Model) updateConfirm(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	if msg.String() == "ctrl+c" {
+		return m, tea.Quit
+	}
+	if m.confirm == nil {
+		return m, nil
+	}
+
+	// The answers to the question are always y and n
+	if m.confirm.word == "" {
+		switch {
+		case msg.String() == "y" || msg.String() == "Y":
+			return m.beginDeleting()
+		case msg.String() == "n" || msg.String() == "N" || key.Matches(msg, m.keys.ClearFilter, m.keys.Quit):
+			m.state = stateSelectFiles
+			m.confirm = nil
+		}
+		return m, nil
+	}
+
+	// Esc and Enter stay fixed while the word is typed, since other keys go into the input
+	if msg.String() == "esc" {
+		m.state = stateSelectFiles
+		m.confirm = nil
+		return m, nil
+	}
+
+	if msg.String() == "enter" {
+		if strings.TrimSpace(m.confirm.input.Value()) == m.confirm.word {
+			return m.beginDeleting()
+		}
+		m.confirm.input.SetValue("")
+		return m, nil
+	}
+
+	var cmd tea.Cmd
+	m.confirm.input, cmd = m.confirm.input.Update(msg)
+	return m, cmd
+}
+
+// confirmView renders the confirmation screen
+func (m Model) confirmView() string {
+	var s strings.Builder
+	s.WriteString(titleStyle.Render("Confirm deletion") + "\n\n")
+
+	c := m.confirm
+	if c == nil {
+		s.WriteString(m.spinner.View() + " Measuring the selected files...\n")
+		return s.String()
+	}
+
+	s.WriteString(fmt.Sprintf("%d items, %s will be deleted for %s\n\n", c.count, units.FormatBytes(c.size), m.appLabel()))
+	for _, category := range finder.Categories {
+		if n := c.categories[category]; n > 0 {
+			s.WriteString(fmt.Sprintf("  %-12s %d\n", category, n))
+		}
+	}
+
+	if len(c.lowConfidence) > 0 {
+		s.WriteString(errorStyle.Render(fmt.Sprintf("\n%d items only matched because their name contains the app name:", len(c.lowConfidence))) + "\n")
+		writePathList(&s, c.lowConfidence)
+	}
+	if len(c.privileged) > 0 {
+		s.WriteString(errorStyle.Render(fmt.Sprintf("\n%d items need administrator rights and are deleted through sudo:", len(c.privileged))) + "\n")
+		writePathList(&s, c.privileged)
+	}
+
+	if c.word == "" {
+		s.WriteString(fmt.Sprintf("\nDelete these files? Press y to confirm, n or %s to go back\n", m.keys.ClearFilter.Help().Key))
+	} else {
+		s.WriteString(fmt.Sprintf("\nThis is a large deletion. Type %q and press Enter to confirm, Esc to go back\n\n", c.word))
+		s.WriteString(c.input.View() + "\n")
+	}
+	return s.String()
+}
+
+// writePathList writes the first few paths of a list and how many were left out
+func writePathList(s *strings.Builder, paths []string) {
+	const shown = 5
+	for i, p := range paths {
+		if i == shown {
+			s.WriteString(fmt.Sprintf("  ... and %d more\n", len(paths)-shown))
+			break
+		}
+		s.WriteString("  " + p + "\n")
+	}
+}
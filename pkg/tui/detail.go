@@ -0,0 +1,176 @@
+package tui
+
+import (
+	"fmt"
+	"os"
+	"os/user"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/alexintosh/gocleaner/pkg/finder"
+	"github.com/alexintosh/gocleaner/pkg/policy"
+	"github.com/alexintosh/gocleaner/pkg/units"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// Number of children listed for a directory in the detail pane
+const detailChildren = 5
+
+// The detail pane sits beside the list on wide terminals and below it otherwise
+const (
+	sidePaneMinWidth = 120
+	bottomPaneHeight = 12
+)
+
+// itemDetail describes the highlighted file in the detail pane
+type itemDetail struct {
+	path     string
+	kind     string
+	owner    string
+	modified time.Time
+	verdict  policy.Verdict
+	children []childSize
+	err      error
+}
+
+// childSize is one entry of a directory along with its size
+type childSize struct {
+	name string
+	size int64
+}
+
+// detailMsg carries the details of a file once they have been gathered
+type detailMsg struct {
+	detail *itemDetail
+}
+
+// highlightedFile returns the file under the cursor, if the cursor is on one
+func (m Model) highlightedFile() (FileItem, bool) {
+	item, ok := m.fileList.SelectedItem().(FileItem)
+	return item, ok
+}
+
+// loadDetail gathers the details of the highlighted file unless they are
+// known already. Directories are measured, so this runs as a command.
+func (m *Model) loadDetail() tea.Cmd {
+	item, ok := m.highlightedFile()
+	if !ok || item.path == m.detailPath {
+		return nil
+	}
+	m.detailPath = item.path
+	if _, ok := m.details[item.path]; ok {
+		return nil
+	}
+
+	appCleaner := m.appCleaner
+	return func() tea.Msg {
+		d := &itemDetail{path: item.path, verdict: appCleaner.Verdict(item.path)}
+		info, err := os.Lstat(item.path)
+		if err != nil {
+			d.err = err
+			return detailMsg{d}
+		}
+
+		d.modified = info.ModTime()
+		switch {
+		case info.Mode()&os.ModeSymlink != 0:
+			d.kind = "symlink"
+		case info.IsDir() && strings.HasSuffix(item.path, ".app"):
+			d.kind = "app bundle"
+		case info.IsDir():
+			d.kind = "directory"
+		default:
+			d.kind = "file"
+		}
+		if stat, ok := info.Sys().(*syscall.Stat_t); ok {
+			uid := strconv.Itoa(int(stat.Uid))
+			d.owner = "uid " + uid
+			if u, err := user.LookupId(uid); err == nil {
+				d.owner = u.Username
+			}
+		}
+
+		if info.IsDir() {
+			entries, _ := os.ReadDir(item.path)
+			for _, entry := range entries {
+				d.children = append(d.children, childSize{entry.Name(), finder.PathSize(filepath.Join(item.path, entry.Name()))})
+			}
+			sort.Slice(d.children, func(i, j int) bool {
+				return d.children[i].size > d.children[j].size
+			})
+		}
+		return detailMsg{d}
+	}
+}
+
+// detailView renders the pane for the highlighted file
+func (m Model) detailView(width, height int) string {
+	var s strings.Builder
+	item, ok := m.highlightedFile()
+	if !ok {
+		if group, ok := m.fileList.SelectedItem().(groupItem); ok {
+			s.WriteString(titleStyle.Render(string(group.group.category)) + "\n\n")
+			s.WriteString(fmt.Sprintf("Root:     %s\n", group.group.root))
+			s.WriteString(fmt.Sprintf("Items:    %d (%d selected)\n", len(m.tree.visibleFiles(group.group)), group.selectedCount()))
+			s.WriteString(fmt.Sprintf("Size:     %s\n", units.FormatBytes(m.tree.visibleSize(group.group))))
+		}
+		return detailStyle.Width(width).MaxHeight(height).Render(s.String())
+	}
+
+	s.WriteString(titleStyle.Render(filepath.Base(item.path)) + "\n\n")
+	s.WriteString(fmt.Sprintf("Size:     %s\n", units.FormatBytes(item.size)))
+	s.WriteString(fmt.Sprintf("Match:    %s (%s confidence)\n", item.match.Reason, item.match.Confidence))
+
+	d, ok := m.details[item.path]
+	switch {
+	case !ok:
+		s.WriteString("\n" + m.spinner.View() + " Reading details...\n")
+	case d.err != nil:
+		s.WriteString(errorStyle.Render(fmt.Sprintf("\n%v", d.err)) + "\n")
+	default:
+		s.WriteString(fmt.Sprintf("Kind:     %s\n", d.kind))
+		s.WriteString(fmt.Sprintf("Owner:    %s\n", d.owner))
+		s.WriteString(fmt.Sprintf("Modified: %s (%s ago)\n", d.modified.Format("2006-01-02 15:04"), units.FormatAge(time.Since(d.modified))))
+		if d.verdict.Allowed {
+			s.WriteString(statusMessageStyle.Render("Policy:   allowed") + "\n")
+		} else {
+			s.WriteString(errorStyle.Render("Policy:   denied, will be skipped") + "\n")
+		}
+		s.WriteString("          " + d.verdict.Reason + "\n")
+
+		if len(d.children) > 0 {
+			s.WriteString(fmt.Sprintf("\nLargest of %d entries:\n", len(d.children)))
+			for i, child := range d.children {
+				if i == detailChildren {
+					break
+				}
+				s.WriteString(fmt.Sprintf("  %9s  %s\n", units.FormatBytes(child.size), child.name))
+			}
+		}
+	}
+	return detailStyle.Width(width).MaxHeight(height).Render(s.String())
+}
+
+// selectView lays out the file list and the detail pane side by side when
+// the terminal is wide enough, and above each other otherwise
+func (m Model) selectView() string {
+	list := fileListStyle.Render(m.fileList.View())
+	if m.width >= sidePaneMinWidth {
+		return lipgloss.JoinHorizontal(lipgloss.Top, list, " ", m.detailView(m.width-lipgloss.Width(list)-10, lipgloss.Height(list)))
+	}
+	return lipgloss.JoinVertical(lipgloss.Left, list, m.detailView(m.width-10, bottomPaneHeight))
+}
+
+// resizeList fits the file list next to or above the detail pane
+func (m *Model) resizeList() {
+	if m.width >= sidePaneMinWidth {
+		m.fileList.SetSize(m.width*3/5, m.height-12)
+		return
+	}
+	m.fileList.SetSize(m.width-4, m.height-12-bottomPaneHeight)
+}
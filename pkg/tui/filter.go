@@ -0,0 +1,183 @@
+package tui
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/alexintosh/gocleaner/pkg/finder"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// sortKey orders the groups and the files within them
+type sortKey string
+
+const (
+	sortCategory   sortKey = "category"
+	sortSize       sortKey = "size"
+	sortPath       sortKey = "path"
+	sortConfidence sortKey = "confidence"
+)
+
+// sortKeys lists the orders s cycles through
+var sortKeys = []sortKey{sortCategory, sortSize, sortPath, sortConfidence}
+
+// next returns the order following k
+func (k sortKey) next() sortKey {
+	for i, key := range sortKeys {
+		if key == k {
+			return sortKeys[(i+1)%len(sortKeys)]
+		}
+	}
+	return sortCategory
+}
+
+// matchesFilter reports whether a file's path, category or match reason
+// contains the filter, ignoring case
+func (t *fileTree) matchesFilter(match finder.Match) bool {
+	if t.filter == "" {
+		return true
+	}
+	filter := strings.ToLower(t.filter)
+	for _, field := range []string{match.Path, string(match.Category), match.Reason} {
+		if strings.Contains(strings.ToLower(field), filter) {
+			return true
+		}
+	}
+	return false
+}
+
+// visibleFiles returns the files of a group that pass the filter, in the
+// current order
+func (t *fileTree) visibleFiles(group *fileGroup) []finder.Match {
+	files := []finder.Match{}
+	for _, match := range group.files {
+		if t.matchesFilter(match) {
+			files = append(files, match)
+		}
+	}
+
+	// Category order keeps the order the files were found in
+	switch t.sortBy {
+	case sortSize:
+		sort.SliceStable(files, func(i, j int) bool { return t.sizes[files[i].Path] > t.sizes[files[j].Path] })
+	case sortPath:
+		sort.SliceStable(files, func(i, j int) bool { return files[i].Path < files[j].Path })
+	case sortConfidence:
+		// Low-confidence matches deserve a second look, so they come first
+		sort.SliceStable(files, func(i, j int) bool {
+			if files[i].Confidence != files[j].Confidence {
+				return files[i].Confidence == finder.ConfidenceLow
+			}
+			return files[i].Path < files[j].Path
+		})
+	}
+	return files
+}
+
+// visibleSize sums the sizes of the files of a group that pass the filter
+func (t *fileTree) visibleSize(group *fileGroup) int64 {
+	var size int64
+	for _, match := range t.visibleFiles(group) {
+		size += t.sizes[match.Path]
+	}
+	return size
+}
+
+// visibleGroups returns the groups with at least one file passing the
+// filter, in the current order
+func (t *fileTree) visibleGroups() []*fileGroup {
+	groups := []*fileGroup{}
+	for _, group := range t.groups {
+		if len(t.visibleFiles(group)) > 0 {
+			groups = append(groups, group)
+		}
+	}
+
+	switch t.sortBy {
+	case sortSize:
+		sort.SliceStable(groups, func(i, j int) bool { return t.visibleSize(groups[i]) > t.visibleSize(groups[j]) })
+	case sortPath:
+		sort.SliceStable(groups, func(i, j int) bool { return groups[i].root < groups[j].root })
+	case sortConfidence:
+		sort.SliceStable(groups, func(i, j int) bool { return t.lowConfidence(groups[i]) > t.lowConfidence(groups[j]) })
+	}
+	return groups
+}
+
+// lowConfidence counts the files of a group passing the filter that only
+// matched on part of their name
+func (t *fileTree) lowConfidence(group *fileGroup) int {
+	n := 0
+	for _, match := range t.visibleFiles(group) {
+		if match.Confidence == finder.ConfidenceLow {
+			n++
+		}
+	}
+	return n
+}
+
+// setFilter changes the filter and expands every group with a matching
+// file, so the matches can be seen
+func (t *fileTree) setFilter(filter string) {
+	t.filter = filter
+	if filter == "" {
+		return
+	}
+	for _, group := range t.visibleGroups() {
+		group.expanded = true
+	}
+}
+
+// startFilter focuses the filter input, keeping the current filter
+func (m Model) startFilter() (tea.Model, tea.Cmd) {
+	m.filtering = true
+	m.filterInput.SetValue(m.tree.filter)
+	m.filterInput.CursorEnd()
+	return m, m.filterInput.Focus()
+}
+
+// updateFilter handles keys while the filter is typed. Enter keeps the
+// filter, Esc clears it.
+func (m Model) updateFilter(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.String() {
+	case "ctrl+c":
+		return m, tea.Quit
+	case "enter":
+		m.filtering = false
+		m.filterInput.Blur()
+		return m, nil
+	case "esc":
+		m.filtering = false
+		m.filterInput.Blur()
+		m.filterInput.SetValue("")
+		m.applyFilter()
+		return m, nil
+	}
+
+	var cmd tea.Cmd
+	m.filterInput, cmd = m.filterInput.Update(msg)
+	if m.filterInput.Value() != m.tree.filter {
+		m.applyFilter()
+	}
+	return m, cmd
+}
+
+// applyFilter filters the list by the text typed so far
+func (m *Model) applyFilter() {
+	m.tree.setFilter(strings.TrimSpace(m.filterInput.Value()))
+	m.fileList.SetItems(m.tree.items())
+	m.fileList.Select(0)
+}
+
+// filterView shows the order of the list and the filter, or the filter
+// input while it is typed
+func (m Model) filterView() string {
+	order := "Sorted by " + string(m.tree.sortBy)
+	switch {
+	case m.filtering:
+		return m.filterInput.View() + "  · " + order
+	case m.tree.filter != "":
+		return statusMessageStyle.Render("Filter: "+m.tree.filter) + "  · " + order
+	}
+	return order
+}
@@ -0,0 +1,173 @@
+package tui
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/key"
+)
+
+// KeyMap holds the key bindings of the file selection. Ctrl+C always quits
+// and cannot be rebound.
+type KeyMap struct {
+	Up          key.Binding
+	Down        key.Binding
+	PrevPage    key.Binding
+	NextPage    key.Binding
+	Expand      key.Binding
+	Collapse    key.Binding
+	ExpandAll   key.Binding
+	CollapseAll key.Binding
+	Toggle      key.Binding
+	SelectAll   key.Binding
+	SelectNone  key.Binding
+	Include     key.Binding
+	Filter      key.Binding
+	ClearFilter key.Binding
+	Sort        key.Binding
+	Confirm     key.Binding
+	Help        key.Binding
+	Quit        key.Binding
+}
+
+// DefaultKeyMap returns the bindings used unless the configuration file
+// changes them
+func DefaultKeyMap() KeyMap {
+	return KeyMap{
+		Up:          key.NewBinding(key.WithKeys("up", "k"), key.WithHelp("↑/k", "move up")),
+		Down:        key.NewBinding(key.WithKeys("down", "j"), key.WithHelp("↓/j", "move down")),
+		PrevPage:    key.NewBinding(key.WithKeys("pgup", "b", "u"), key.WithHelp("pgup", "previous page")),
+		NextPage:    key.NewBinding(key.WithKeys("pgdown", "f", "d"), key.WithHelp("pgdn", "next page")),
+		Expand:      key.NewBinding(key.WithKeys("right", "l"), key.WithHelp("→/l", "expand group, open folder")),
+		Collapse:    key.NewBinding(key.WithKeys("left", "h"), key.WithHelp("←/h", "collapse group, leave folder")),
+		ExpandAll:   key.NewBinding(key.WithKeys("+"), key.WithHelp("+", "expand all groups")),
+		CollapseAll: key.NewBinding(key.WithKeys("-"), key.WithHelp("-", "collapse all groups")),
+		Toggle:      key.NewBinding(key.WithKeys(" "), key.WithHelp("space", "toggle file or group")),
+		SelectAll:   key.NewBinding(key.WithKeys("a"), key.WithHelp("a", "select all")),
+		SelectNone:  key.NewBinding(key.WithKeys("n"), key.WithHelp("n", "select none")),
+		Include:     key.NewBinding(key.WithKeys("i"), key.WithHelp("i", "add files the scan missed")),
+		Filter:      key.NewBinding(key.WithKeys("/"), key.WithHelp("/", "filter")),
+		ClearFilter: key.NewBinding(key.WithKeys("esc"), key.WithHelp("esc", "clear filter, go back")),
+		Sort:        key.NewBinding(key.WithKeys("s"), key.WithHelp("s", "change order")),
+		Confirm:     key.NewBinding(key.WithKeys("enter"), key.WithHelp("enter", "review and delete")),
+		Help:        key.NewBinding(key.WithKeys("?"), key.WithHelp("?", "help")),
+		Quit:        key.NewBinding(key.WithKeys("q"), key.WithHelp("q", "quit")),
+	}
+}
+
+// actions maps the names used in the [keys] section of the configuration
+// file to the bindings they change
+func (k *KeyMap) actions() map[string]*key.Binding {
+	return map[string]*key.Binding{
+		"up":           &k.Up,
+		"down":         &k.Down,
+		"prev_page":    &k.PrevPage,
+		"next_page":    &k.NextPage,
+		"expand":       &k.Expand,
+		"collapse":     &k.Collapse,
+		"expand_all":   &k.ExpandAll,
+		"collapse_all": &k.CollapseAll,
+		"toggle":       &k.Toggle,
+		"select_all":   &k.SelectAll,
+		"select_none":  &k.SelectNone,
+		"include":      &k.Include,
+		"filter":       &k.Filter,
+		"clear_filter": &k.ClearFilter,
+		"sort":         &k.Sort,
+		"confirm":      &k.Confirm,
+		"help":         &k.Help,
+		"quit":         &k.Quit,
+	}
+}
+
+// Override replaces the keys of the named actions. Keys are written as
+// bubbletea names them, e.g. "x", "ctrl+d", "pgdown" or "space". A key
+// left bound to two actions, or to ctrl+c, is refused.
+func (k *KeyMap) Override(keys map[string][]string) error {
+	actions := k.actions()
+
+	names := make([]string, 0, len(keys))
+	for name := range keys {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		binding, ok := actions[name]
+		if !ok {
+			return fmt.Errorf("unknown key action %q", name)
+		}
+		if len(keys[name]) == 0 {
+			return fmt.Errorf("no keys given for %q", name)
+		}
+
+		bound := []string{}
+		for _, pressed := range keys[name] {
+			if strings.TrimSpace(pressed) == "" {
+				return fmt.Errorf("empty key given for %q", name)
+			}
+			if pressed == "space" {
+				pressed = " "
+			}
+			if pressed == "ctrl+c" {
+				return fmt.Errorf("ctrl+c always quits and cannot be given to %q", name)
+			}
+			bound = append(bound, pressed)
+		}
+
+		// The help shows the new keys next to the old description
+		shown := strings.Join(keys[name], "/")
+		binding.SetKeys(bound...)
+		binding.SetHelp(shown, binding.Help().Desc)
+	}
+	return k.checkConflicts()
+}
+
+// checkConflicts makes sure no key is bound to two actions, since only one
+// of them would ever run
+func (k *KeyMap) checkConflicts() error {
+	actions := k.actions()
+	names := make([]string, 0, len(actions))
+	for name := range actions {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	owners := map[string]string{}
+	for _, name := range names {
+		for _, pressed := range actions[name].Keys() {
+			if other, ok := owners[pressed]; ok && other != name {
+				if pressed == " " {
+					pressed = "space"
+				}
+				return fmt.Errorf("key %q is bound to both %q and %q", pressed, other, name)
+			}
+			owners[pressed] = name
+		}
+	}
+	return nil
+}
+
+// ShortHelp lists the bindings shown below the file list
+func (k KeyMap) ShortHelp() []key.Binding {
+	return []key.Binding{k.Toggle, k.Confirm, k.Filter, k.Help, k.Quit}
+}
+
+// FullHelp lists every binding for the help overlay, in columns
+func (k KeyMap) FullHelp() [][]key.Binding {
+	return [][]key.Binding{
+		{k.Up, k.Down, k.PrevPage, k.NextPage, k.Expand, k.Collapse},
+		{k.ExpandAll, k.CollapseAll, k.Toggle, k.SelectAll, k.SelectNone, k.Include},
+		{k.Filter, k.ClearFilter, k.Sort, k.Confirm, k.Help, k.Quit},
+	}
+}
+
+// helpView renders the help overlay from the key map
+func (m Model) helpView() string {
+	var s strings.Builder
+	s.WriteString(titleStyle.Render("Keys") + "\n\n")
+	s.WriteString(m.help.FullHelpView(m.keys.FullHelp()) + "\n\n")
+	s.WriteString(fmt.Sprintf("Press %s or %s to close this help\n", m.keys.Help.Help().Key, m.keys.ClearFilter.Help().Key))
+	return s.String()
+}
@@ -1,15 +1,22 @@
 package tui
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"strings"
-	"time"
 
 	"github.com/alexintosh/gocleaner/pkg/cleaner"
+	"github.com/alexintosh/gocleaner/pkg/events"
 	"github.com/alexintosh/gocleaner/pkg/finder"
+	"github.com/alexintosh/gocleaner/pkg/units"
+	"github.com/charmbracelet/bubbles/help"
+	"github.com/charmbracelet/bubbles/key"
 	"github.com/charmbracelet/bubbles/list"
 	"github.com/charmbracelet/bubbles/progress"
 	"github.com/charmbracelet/bubbles/spinner"
+	"github.com/charmbracelet/bubbles/textinput"
+	"github.com/charmbracelet/bubbles/viewport"
 	tea "github.com/charmbracelet/bubbletea"
 )
 
@@ -17,25 +24,32 @@ import (
 const (
 	stateScanning   = "scanning"
 	stateSelectFiles = "select_files"
+	stateBrowse     = "browse"
+	stateConfirm    = "confirm"
 	stateDeleting   = "deleting"
+	stateSummary    = "summary"
 	stateDone       = "done"
 )
 
-// FileItem represents a file in the list
+// FileItem represents a file in the list, indented under its group
 type FileItem struct {
 	path     string
+	app      string
+	match    finder.Match
+	size     int64
 	selected bool
 }
 
 func (i FileItem) Title() string {
 	if i.selected {
-		return checkboxChecked.String() + i.path
+		return "    " + checkboxChecked.String() + i.path
 	}
-	return checkboxUnchecked.String() + i.path
+	return "    " + checkboxUnchecked.String() + i.path
 }
 
+// Description shows the size of the file and why it matched
 func (i FileItem) Description() string {
-	return ""
+	return fmt.Sprintf("        %s · %s", units.FormatBytes(i.size), i.match.Reason)
 }
 
 func (i FileItem) FilterValue() string {
@@ -44,26 +58,50 @@ func (i FileItem) FilterValue() string {
 
 // Model represents the TUI state
 type Model struct {
-	appName      string
+	appNames     []string
 	dryRun       bool
 	force        bool
 	verbose      bool
+	categories   []finder.Category
+	include      []finder.Match
 	state        string
 	spinner      spinner.Model
 	fileList     list.Model
+	tree         *fileTree
+	filterInput  textinput.Model
+	filtering    bool
+	keys         KeyMap
+	help         help.Model
+	showHelp     bool
+	browser      *browser
+	details      map[string]*itemDetail
+	detailPath   string
 	progress     progress.Model
 	files        []string
+	results      []finder.AppMatches
+	conflicts    []finder.Conflict
 	selectedFiles []string
+	confirm      *confirmation
+	progressInfo deleteProgress
+	outcome      deleteOutcome
+	deleteCtx    context.Context
+	cancelDelete context.CancelFunc
+	cancelling   bool
+	cancelled    bool
+	summary      viewport.Model
 	errorMsg     string
 	statusMsg    string
-	appFinder    *finder.AppFinder
+	onScanEvent  events.Handler
 	appCleaner   *cleaner.AppCleaner
+	eventCh      chan events.Event
+	scanRoot     string
+	matchCount   int
 	width        int
 	height       int
 }
 
 // NewModel creates a new TUI model
-func NewModel(appName string, dryRun, force, verbose bool) Model {
+func NewModel(appNames []string, opts Options) Model {
 	s := spinner.New()
 	s.Spinner = spinner.Dot
 	s.Style = spinnerStyle
@@ -75,20 +113,55 @@ func NewModel(appName string, dryRun, force, verbose bool) Model {
 	fileList.Title = "Files to be removed:"
 	fileList.SetFilteringEnabled(false)
 	fileList.SetShowStatusBar(false)
-	fileList.SetShowHelp(true)
+	fileList.SetShowHelp(false)
 	fileList.Styles.Title = titleStyle
 
+	keys := DefaultKeyMap()
+	if opts.Keys != nil {
+		keys = *opts.Keys
+	}
+
+	// The list moves the cursor itself, with the keys of the key map
+	fileList.KeyMap.CursorUp = keys.Up
+	fileList.KeyMap.CursorDown = keys.Down
+	fileList.KeyMap.PrevPage = keys.PrevPage
+	fileList.KeyMap.NextPage = keys.NextPage
+	fileList.KeyMap.Quit = keys.Quit
+
+	filterInput := textinput.New()
+	filterInput.Prompt = "/"
+	filterInput.Placeholder = "path, category or reason"
+
+	// The finder reports its progress through the same events used by the
+	// NDJSON stream; they are forwarded to Update as eventMsg
+	eventCh := make(chan events.Event)
+
+	appCleaner := cleaner.NewAppCleaner(opts.Verbose)
+	appCleaner.SetAllowForeignOwner(opts.AllowForeignOwner)
+	if opts.Privileged != nil {
+		appCleaner.SetPrivilegedDeleter(opts.Privileged)
+	}
+
 	return Model{
-		appName:    appName,
-		dryRun:     dryRun,
-		force:      force,
-		verbose:    verbose,
+		appNames:   appNames,
+		dryRun:     opts.DryRun,
+		force:      opts.Force,
+		verbose:    opts.Verbose,
+		categories: opts.Categories,
+		include:    opts.Include,
 		state:      stateScanning,
 		spinner:    s,
 		progress:   p,
 		fileList:   fileList,
-		appFinder:  finder.NewAppFinder(verbose),
-		appCleaner: cleaner.NewAppCleaner(verbose),
+		filterInput: filterInput,
+		keys:       keys,
+		help:       help.New(),
+		details:    map[string]*itemDetail{},
+		appCleaner: appCleaner,
+		eventCh:    eventCh,
+		onScanEvent: func(e events.Event) {
+			eventCh <- e
+		},
 	}
 }
 
@@ -97,141 +170,263 @@ func (m Model) Init() tea.Cmd {
 	return tea.Batch(
 		m.spinner.Tick,
 		m.scanFiles,
+		waitForEvent(m.eventCh),
 	)
 }
 
-// scanFiles searches for files associated with the app
+// waitForEvent waits for the next event emitted by the finder or cleaner
+func waitForEvent(ch chan events.Event) tea.Cmd {
+	return func() tea.Msg {
+		return eventMsg{<-ch}
+	}
+}
+
+// appLabel names the apps being uninstalled
+func (m Model) appLabel() string {
+	return strings.Join(m.appNames, ", ")
+}
+
+// scanFiles searches for files associated with every app
 func (m Model) scanFiles() tea.Msg {
-	files, err := m.appFinder.FindAllAssociatedFiles(m.appName)
+	results, conflicts, err := finder.FindBatch(m.appNames, func() *finder.AppFinder {
+		f := finder.NewAppFinder(m.verbose)
+		f.SetEventHandler(m.onScanEvent)
+		f.SetCategories(m.categories)
+		return f
+	})
 	if err != nil {
 		return errMsg{err}
 	}
+	results = finder.IncludeMatches(results, m.include)
 
-	return filesFoundMsg{files}
+	// Sizes are needed for the group totals, so they are measured while the spinner still runs
+	sizes := map[string]int64{}
+	for _, result := range results {
+		for _, match := range result.Matches {
+			sizes[match.Path] = finder.PathSize(match.Path)
+		}
+	}
+
+	return filesFoundMsg{results, conflicts, sizes}
 }
 
 // Update handles UI state changes
 func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	model, cmd := m.update(msg)
+
+	// The detail pane follows the cursor through the file list
+	if next, ok := model.(Model); ok && next.state == stateSelectFiles {
+		if detailCmd := next.loadDetail(); detailCmd != nil {
+			return next, tea.Batch(cmd, detailCmd)
+		}
+		return next, cmd
+	}
+	return model, cmd
+}
+
+// update handles a message for Update
+func (m Model) update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	var cmd tea.Cmd
 
 	switch msg := msg.(type) {
 	case tea.WindowSizeMsg:
 		m.width = msg.Width
 		m.height = msg.Height
-		m.fileList.SetSize(msg.Width-4, msg.Height-10)
+		m.resizeList()
+		m.help.Width = msg.Width - 6
+		if m.browser != nil {
+			m.browser.entries.SetSize(m.fileList.Width(), m.height-10)
+		}
 		m.progress.Width = msg.Width - 10
+		m.summary.Width = msg.Width - 8
+		m.summary.Height = msg.Height - 8
 		return m, nil
 
 	case tea.KeyMsg:
-		switch msg.String() {
-		case "ctrl+c", "q":
+		// The confirmation may be typed into, so it sees keys first
+		if m.state == stateConfirm {
+			return m.updateConfirm(msg)
+		}
+		if m.state == stateSummary {
+			return m.updateSummary(msg)
+		}
+		if m.state == stateSelectFiles && m.filtering {
+			return m.updateFilter(msg)
+		}
+		if m.state == stateBrowse {
+			return m.updateBrowse(msg)
+		}
+
+		// The help overlay hides the list until it is closed
+		if m.state == stateSelectFiles && m.showHelp && msg.String() != "ctrl+c" {
+			if key.Matches(msg, m.keys.Help, m.keys.ClearFilter, m.keys.Quit) {
+				m.showHelp = false
+			}
+			return m, nil
+		}
+
+		// Ctrl+C quits whatever the bindings say
+		if msg.String() == "ctrl+c" || key.Matches(msg, m.keys.Quit) {
+			// The first interrupt lets the current item finish and ends on a partial summary
+			if m.state == stateDeleting && !m.cancelling {
+				m.cancelling = true
+				m.cancelDelete()
+				return m, nil
+			}
 			return m, tea.Quit
+		}
+		if m.state != stateSelectFiles {
+			break
+		}
+
+		switch {
+		case key.Matches(msg, m.keys.Help):
+			m.showHelp = true
+			return m, nil
 
-		case " ":
-			if m.state == stateSelectFiles {
-				index := m.fileList.Index()
-				if index >= 0 && index < len(m.files) {
-					fileItems := []list.Item{}
-					for i, item := range m.fileList.Items() {
-						fileItem := item.(FileItem)
-						if i == index {
-							fileItem.selected = !fileItem.selected
-						}
-						fileItems = append(fileItems, fileItem)
-					}
-					m.fileList.SetItems(fileItems)
-				}
+		case key.Matches(msg, m.keys.Toggle):
+			if item := m.fileList.SelectedItem(); item != nil {
+				m.tree.toggle(item)
+				m.refreshList()
 			}
 			return m, nil
 
-		case "enter":
-			if m.state == stateSelectFiles {
-				// Get selected files
-				selectedFiles := []string{}
-				for _, item := range m.fileList.Items() {
-					fileItem := item.(FileItem)
-					if fileItem.selected {
-						selectedFiles = append(selectedFiles, fileItem.path)
-					}
-				}
-				m.selectedFiles = selectedFiles
-
-				if len(selectedFiles) == 0 {
-					return m, tea.Quit
-				}
-
-				// If dry run, just exit
-				if m.dryRun {
-					m.state = stateDone
-					m.statusMsg = fmt.Sprintf("Dry run complete. %d files would be deleted.", len(selectedFiles))
-					return m, tea.Quit
-				}
-
-				// If force or if we confirmed, start deleting
-				if m.force {
-					m.state = stateDeleting
-					return m, m.startDeleting
-				}
-
-				m.state = stateDeleting
-				return m, m.startDeleting
+		case key.Matches(msg, m.keys.Expand):
+			if item, ok := m.fileList.SelectedItem().(groupItem); ok && !item.group.expanded {
+				item.group.expanded = true
+				m.refreshList()
 			}
 			return m, nil
 
-		case "a":
-			if m.state == stateSelectFiles {
-				fileItems := []list.Item{}
-				for _, item := range m.fileList.Items() {
-					fileItem := item.(FileItem)
-					fileItem.selected = true
-					fileItems = append(fileItems, fileItem)
-				}
-				m.fileList.SetItems(fileItems)
+		case key.Matches(msg, m.keys.Collapse):
+			m.collapseAtCursor()
+			return m, nil
+
+		case key.Matches(msg, m.keys.Confirm):
+			// Get selected files
+			selectedMatches := m.tree.selectedMatches()
+			m.selectedFiles = finder.Paths(selectedMatches)
+
+			if len(m.selectedFiles) == 0 {
+				return m, tea.Quit
+			}
+
+			// If dry run, just exit
+			if m.dryRun {
+				m.state = stateDone
+				m.statusMsg = fmt.Sprintf("Dry run complete. %d files would be deleted.", len(m.selectedFiles))
+				return m, tea.Quit
 			}
+
+			// Nothing is deleted before the selection has been confirmed
+			m.state = stateConfirm
+			m.confirm = nil
+			return m, m.prepareConfirm(selectedMatches)
+
+		case key.Matches(msg, m.keys.SelectAll, m.keys.SelectNone):
+			m.tree.setAll(key.Matches(msg, m.keys.SelectAll))
+			m.refreshList()
 			return m, nil
 
-		case "n":
-			if m.state == stateSelectFiles {
-				fileItems := []list.Item{}
-				for _, item := range m.fileList.Items() {
-					fileItem := item.(FileItem)
-					fileItem.selected = false
-					fileItems = append(fileItems, fileItem)
-				}
-				m.fileList.SetItems(fileItems)
+		case key.Matches(msg, m.keys.ExpandAll, m.keys.CollapseAll):
+			m.tree.setExpanded(key.Matches(msg, m.keys.ExpandAll))
+			m.refreshList()
+			return m, nil
+
+		case key.Matches(msg, m.keys.Filter):
+			return m.startFilter()
+
+		case key.Matches(msg, m.keys.ClearFilter):
+			if m.tree.filter != "" {
+				m.filterInput.SetValue("")
+				m.applyFilter()
 			}
 			return m, nil
+
+		case key.Matches(msg, m.keys.Include):
+			return m.openBrowser()
+
+		case key.Matches(msg, m.keys.Sort):
+			m.tree.sortBy = m.tree.sortBy.next()
+			m.refreshList()
+			return m, nil
 		}
 
+	case eventMsg:
+		switch msg.event.Type {
+		case events.ScanRootStarted:
+			m.scanRoot = msg.event.Root
+		case events.MatchFound:
+			m.matchCount++
+		case events.ItemDeleted, events.ItemSkipped, events.ItemFailed:
+			m.progressInfo.record(msg.event, m.confirm.sizes[msg.event.Path])
+			m.outcome.record(msg.event)
+			return m, tea.Batch(waitForEvent(m.eventCh), m.progress.SetPercent(m.progressInfo.percent()))
+		case events.Summary:
+			m.cancelled = msg.event.Totals.Cancelled
+			return m.showSummary()
+		}
+		return m, waitForEvent(m.eventCh)
+
+	case includedMsg:
+		return m.included(msg)
+
+	case detailMsg:
+		m.details[msg.detail.path] = msg.detail
+		return m, nil
+
+	case confirmReadyMsg:
+		m.confirm = msg.confirm
+
+		// --force skips the question, but the sizes are still needed for progress
+		if m.force && !m.dryRun {
+			return m.beginDeleting()
+		}
+		return m, nil
+
 	case spinner.TickMsg:
 		m.spinner, cmd = m.spinner.Update(msg)
 		return m, cmd
 
 	case filesFoundMsg:
-		m.files = msg.files
+		m.results = msg.results
+		m.conflicts = msg.conflicts
 		m.state = stateSelectFiles
 
-		fileItems := []list.Item{}
-		for _, file := range m.files {
-			fileItems = append(fileItems, FileItem{
-				path:     file,
-				selected: true,
-			})
+		// Files are grouped by app, category and root
+		for _, result := range m.results {
+			for _, match := range result.Matches {
+				m.files = append(m.files, match.Path)
+			}
 		}
-		m.fileList.SetItems(fileItems)
+		m.tree = newFileTree(m.results, msg.sizes, len(m.appNames) > 1)
+		m.fileList.SetItems(m.tree.items())
 
 		// If no files found
 		if len(m.files) == 0 {
 			m.state = stateDone
-			m.statusMsg = fmt.Sprintf("No files found for %s", m.appName)
+			m.statusMsg = fmt.Sprintf("No files found for %s", m.appLabel())
+			return m, tea.Quit
+		}
+
+		// A dry run never deletes, even with --force
+		if m.force && m.dryRun {
+			m.selectedFiles = m.files
+			m.state = stateDone
+			m.statusMsg = fmt.Sprintf("Dry run complete. %d files would be deleted.", len(m.selectedFiles))
 			return m, tea.Quit
 		}
 
 		// If force flag is set, skip selection and go straight to deletion
+		// once the files have been measured
 		if m.force {
 			m.selectedFiles = m.files
-			m.state = stateDeleting
-			return m, m.startDeleting
+			m.state = stateConfirm
+			matches := []finder.Match{}
+			for _, result := range m.results {
+				matches = append(matches, result.Matches...)
+			}
+			return m, m.prepareConfirm(matches)
 		}
 
 		return m, nil
@@ -246,14 +441,6 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		m.progress = progressModel.(progress.Model)
 		return m, cmd
 
-	case progressUpdateMsg:
-		if msg.done {
-			m.state = stateDone
-			m.statusMsg = fmt.Sprintf("Successfully deleted %d files.", msg.count)
-			return m, tea.Quit
-		}
-		cmd := m.progress.SetPercent(float64(msg.current) / float64(msg.total))
-		return m, cmd
 	}
 
 	// Update list when in select files state
@@ -274,18 +461,36 @@ func (m Model) View() string {
 	case stateScanning:
 		s.WriteString(m.spinner.View())
 		s.WriteString(" Scanning for files associated with ")
-		s.WriteString(titleStyle.Render(m.appName))
+		s.WriteString(titleStyle.Render(m.appLabel()))
 		s.WriteString("...\n")
+		if m.scanRoot != "" {
+			s.WriteString(fmt.Sprintf("\nScanning %s (%d found so far)\n", m.scanRoot, m.matchCount))
+		}
 
 	case stateSelectFiles:
-		s.WriteString(titleStyle.Render(fmt.Sprintf("Found %d files for %s\n\n", len(m.files), m.appName)))
-		s.WriteString(fileListStyle.Render(m.fileList.View()))
-		s.WriteString("\nUse arrow keys to navigate, space to toggle selection, a to select all, n to select none\n")
-		s.WriteString("Press Enter to confirm or Ctrl+C to quit\n")
+		s.WriteString(titleStyle.Render(fmt.Sprintf("Found %d files for %s", len(m.files), m.appLabel())) + "\n\n")
+		if len(m.conflicts) > 0 {
+			s.WriteString(errorStyle.Render(fmt.Sprintf("%d paths are claimed by more than one app and are listed once", len(m.conflicts))) + "\n")
+		}
+		if m.showHelp {
+			s.WriteString(m.helpView())
+			break
+		}
+		s.WriteString(m.filterView() + "\n")
+		s.WriteString(m.selectView())
+		s.WriteString("\n" + m.help.ShortHelpView(m.keys.ShortHelp()) + "\n")
+
+	case stateBrowse:
+		s.WriteString(m.browseView())
+
+	case stateConfirm:
+		s.WriteString(m.confirmView())
+
+	case stateSummary:
+		s.WriteString(m.summaryView())
 
 	case stateDeleting:
-		s.WriteString(titleStyle.Render("Deleting files...\n\n"))
-		s.WriteString(m.progress.View() + "\n\n")
+		s.WriteString(m.deletingView())
 
 	case stateDone:
 		if m.errorMsg != "" {
@@ -298,40 +503,87 @@ func (m Model) View() string {
 	return appStyle.Render(s.String())
 }
 
-// startDeleting begins the file deletion process
-func (m Model) startDeleting() tea.Msg {
-	totalFiles := len(m.selectedFiles)
-	deletedCount := 0
+// refreshList rebuilds the list rows after the tree changed
+func (m *Model) refreshList() {
+	index := m.fileList.Index()
+	m.fileList.SetItems(m.tree.items())
+	if index >= len(m.fileList.Items()) {
+		index = len(m.fileList.Items()) - 1
+	}
+	m.fileList.Select(index)
+}
 
-	// Send initial progress update
-	time.Sleep(100 * time.Millisecond)
-	tea.Tick(time.Millisecond*10, func(t time.Time) tea.Msg {
-		return progressUpdateMsg{current: 0, total: totalFiles, count: 0, done: false}
-	})
+// collapseAtCursor collapses the group under the cursor, or the group of the
+// file under the cursor, and moves the cursor onto the group
+func (m *Model) collapseAtCursor() {
+	var group *fileGroup
+	switch item := m.fileList.SelectedItem().(type) {
+	case groupItem:
+		group = item.group
+	case FileItem:
+		if index := groupIndex(m.fileList.Items(), item.path); index >= 0 {
+			group = m.fileList.Items()[index].(groupItem).group
+		}
+	}
+	if group == nil || !group.expanded {
+		return
+	}
 
-	// Delete files
-	for i, file := range m.selectedFiles {
-		if m.appCleaner.IsSafeToDelete(file) {
-			err := m.appCleaner.DeleteSingleFile(file)
-			if err == nil {
-				deletedCount++
-			}
+	group.expanded = false
+	m.fileList.SetItems(m.tree.items())
+	for i, item := range m.fileList.Items() {
+		if g, ok := item.(groupItem); ok && g.group == group {
+			m.fileList.Select(i)
+			break
 		}
+	}
+}
+
+// beginDeleting switches to the progress screen and starts deleting the
+// selected files
+func (m Model) beginDeleting() (tea.Model, tea.Cmd) {
+	m.state = stateDeleting
+	m.progressInfo = deleteProgress{totalItems: len(m.selectedFiles), totalBytes: m.confirm.size}
+	m.deleteCtx, m.cancelDelete = context.WithCancel(context.Background())
+	return m, m.startDeleting
+}
 
-		// Update progress
-		time.Sleep(100 * time.Millisecond)
-		tea.Tick(time.Millisecond*10, func(t time.Time) tea.Msg {
-			return progressUpdateMsg{current: i + 1, total: totalFiles, count: deletedCount, done: false}
-		})
+// startDeleting deletes the selected files. Every outcome is sent to the
+// event channel as it happens, followed by a summary once all are done or
+// the deletion was cancelled.
+func (m Model) startDeleting() tea.Msg {
+	totals := &events.Totals{Found: len(m.selectedFiles)}
+	m.appCleaner.SetEventHandler(func(e events.Event) {
+		switch e.Type {
+		case events.ItemSkipped:
+			totals.Skipped++
+		case events.ItemFailed:
+			totals.Failed++
+		}
+		m.eventCh <- e
+	})
+
+	// Files that need root are left to the privileged deleter
+	deleted, err := m.appCleaner.DeleteFilesContext(m.deleteCtx, m.selectedFiles)
+	totals.Cancelled = errors.Is(err, context.Canceled)
+	if err != nil && !totals.Cancelled {
+		return errMsg{err}
 	}
+	totals.Deleted = deleted
 
-	// Final update
-	return progressUpdateMsg{current: totalFiles, total: totalFiles, count: deletedCount, done: true}
+	m.eventCh <- events.Event{Type: events.Summary, Totals: totals}
+	return nil
 }
 
 // Messages
 type filesFoundMsg struct {
-	files []string
+	results   []finder.AppMatches
+	conflicts []finder.Conflict
+	sizes     map[string]int64
+}
+
+type eventMsg struct {
+	event events.Event
 }
 
 type errMsg struct {
@@ -339,10 +591,4 @@ type errMsg struct {
 }
 
 func (e errMsg) Error() string { return e.err.Error() }
-
-type progressUpdateMsg struct {
-	current int
-	total   int
-	count   int
-	done    bool
-} 
\ No newline at end of file
+ 
\ No newline at end of file
@@ -0,0 +1,212 @@
+package tui
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/alexintosh/gocleaner/pkg/finder"
+	"github.com/alexintosh/gocleaner/pkg/units"
+	"github.com/charmbracelet/bubbles/key"
+	"github.com/charmbracelet/bubbles/list"
+	"github.com/charmbracelet/bubbles/spinner"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// appItem represents an installed app in the picker
+type appItem struct {
+	app      finder.App
+	lastUsed time.Time
+	selected map[string]bool
+}
+
+func (i appItem) Title() string {
+	if i.selected[i.app.Path] {
+		return checkboxChecked.String() + i.app.Name
+	}
+	return checkboxUnchecked.String() + i.app.Name
+}
+
+// Description shows the version and size of the app and when it was last opened
+func (i appItem) Description() string {
+	version := i.app.Version
+	if version == "" {
+		version = "unknown version"
+	}
+	lastUsed := "last use unknown"
+	if !i.lastUsed.IsZero() {
+		lastUsed = fmt.Sprintf("last used %s (%s ago)", i.lastUsed.Format("2006-01-02"), units.FormatAge(time.Since(i.lastUsed)))
+	}
+	return fmt.Sprintf("    %s · %s · %s", version, units.FormatBytes(i.app.Size), lastUsed)
+}
+
+// FilterValue lets the picker be searched by name and bundle ID
+func (i appItem) FilterValue() string {
+	return i.app.Name + " " + i.app.BundleID
+}
+
+// appsLoadedMsg carries the installed apps once they have been measured
+type appsLoadedMsg struct {
+	apps     []finder.App
+	lastUsed map[string]time.Time
+}
+
+// pickerModel lists the installed apps so the ones to uninstall can be chosen
+type pickerModel struct {
+	spinner  spinner.Model
+	appList  list.Model
+	loading  bool
+	selected map[string]bool
+	picked   []string
+	errorMsg string
+	keys     KeyMap
+}
+
+// newPickerModel creates the app picker with the keys of the file selection
+func newPickerModel(keys KeyMap) pickerModel {
+	s := spinner.New()
+	s.Spinner = spinner.Dot
+	s.Style = spinnerStyle
+
+	appList := list.New([]list.Item{}, list.NewDefaultDelegate(), 0, 0)
+	appList.Title = "Installed applications"
+	appList.SetShowStatusBar(true)
+	appList.SetStatusBarItemName("app", "apps")
+	appList.Styles.Title = titleStyle
+	appList.SetShowHelp(false)
+	appList.KeyMap.CursorUp = keys.Up
+	appList.KeyMap.CursorDown = keys.Down
+	appList.KeyMap.PrevPage = keys.PrevPage
+	appList.KeyMap.NextPage = keys.NextPage
+	appList.KeyMap.Filter = keys.Filter
+	appList.KeyMap.ClearFilter = keys.ClearFilter
+
+	// Going back from the list closes the picker, once any search is cleared
+	quit := append(append([]string{}, keys.Quit.Keys()...), keys.ClearFilter.Keys()...)
+	appList.KeyMap.Quit = key.NewBinding(key.WithKeys(quit...))
+
+	return pickerModel{
+		spinner:  s,
+		appList:  appList,
+		loading:  true,
+		selected: map[string]bool{},
+		keys:     keys,
+	}
+}
+
+func (m pickerModel) Init() tea.Cmd {
+	return tea.Batch(m.spinner.Tick, loadApps)
+}
+
+// loadApps lists the installed apps along with their sizes and last use
+func loadApps() tea.Msg {
+	apps, err := finder.ListApps()
+	if err != nil {
+		return errMsg{err}
+	}
+	return appsLoadedMsg{apps, finder.LastUsedDates(apps)}
+}
+
+func (m pickerModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		m.appList.SetSize(msg.Width-4, msg.Height-6)
+		return m, nil
+
+	case spinner.TickMsg:
+		var cmd tea.Cmd
+		m.spinner, cmd = m.spinner.Update(msg)
+		return m, cmd
+
+	case appsLoadedMsg:
+		m.loading = false
+		if len(msg.apps) == 0 {
+			m.errorMsg = "no applications found"
+			return m, tea.Quit
+		}
+		items := make([]list.Item, 0, len(msg.apps))
+		for _, app := range msg.apps {
+			items = append(items, appItem{app: app, lastUsed: msg.lastUsed[app.Path], selected: m.selected})
+		}
+		return m, m.appList.SetItems(items)
+
+	case errMsg:
+		m.errorMsg = msg.err.Error()
+		return m, tea.Quit
+
+	case tea.KeyMsg:
+		if msg.String() == "ctrl+c" {
+			return m, tea.Quit
+		}
+
+		// While a search is typed every key belongs to it
+		if m.loading || m.appList.SettingFilter() {
+			break
+		}
+		switch {
+		case key.Matches(msg, m.keys.Toggle):
+			if item, ok := m.appList.SelectedItem().(appItem); ok {
+				m.selected[item.app.Path] = !m.selected[item.app.Path]
+			}
+			return m, nil
+
+		case key.Matches(msg, m.keys.Confirm):
+			// Without a selection the highlighted app is uninstalled
+			for _, item := range m.appList.Items() {
+				// Apps of the same name in several locations are one app to the finder
+				if app := item.(appItem).app; m.selected[app.Path] && !slices.Contains(m.picked, app.Name) {
+					m.picked = append(m.picked, app.Name)
+				}
+			}
+			if len(m.picked) == 0 {
+				if item, ok := m.appList.SelectedItem().(appItem); ok {
+					m.picked = []string{item.app.Name}
+				}
+			}
+			return m, tea.Quit
+		}
+	}
+
+	if m.loading {
+		return m, nil
+	}
+	var cmd tea.Cmd
+	m.appList, cmd = m.appList.Update(msg)
+	return m, cmd
+}
+
+func (m pickerModel) View() string {
+	var s strings.Builder
+	if m.loading {
+		s.WriteString(m.spinner.View() + " Looking for installed applications...\n")
+		return appStyle.Render(s.String())
+	}
+
+	s.WriteString(fileListStyle.Render(m.appList.View()))
+	s.WriteString(fmt.Sprintf("\nPress %s to search, %s to select an app, %s to continue with the selected apps (or the highlighted one), %s to quit\n",
+		m.keys.Filter.Help().Key, m.keys.Toggle.Help().Key, m.keys.Confirm.Help().Key, m.keys.Quit.Help().Key))
+	return appStyle.Render(s.String())
+}
+
+// PickApps lets the user choose installed apps to uninstall, with keys
+// replacing the default bindings unless nil. It returns no names when the
+// picker was closed without choosing.
+func PickApps(keys *KeyMap) ([]string, error) {
+	keyMap := DefaultKeyMap()
+	if keys != nil {
+		keyMap = *keys
+	}
+	p := tea.NewProgram(newPickerModel(keyMap), tea.WithAltScreen())
+	m, err := p.Run()
+	if err != nil {
+		return nil, fmt.Errorf("error running TUI: %w", err)
+	}
+
+	finalModel := m.(pickerModel)
+	if finalModel.errorMsg != "" {
+		return nil, errors.New(finalModel.errorMsg)
+	}
+	return finalModel.picked, nil
+}
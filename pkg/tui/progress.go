@@ -0,0 +1,58 @@
+package tui
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/alexintosh/gocleaner/pkg/events"
+	"github.com/alexintosh/gocleaner/pkg/units"
+)
+
+// deleteProgress tracks how far the deletion has come from the events the
+// cleaner emits for every item
+type deleteProgress struct {
+	totalItems int
+	totalBytes int64
+	doneItems  int
+	doneBytes  int64
+	freedBytes int64
+	current    string
+}
+
+// record counts an item as handled. Only deleted items free their bytes.
+func (p *deleteProgress) record(e events.Event, size int64) {
+	p.doneItems++
+	p.doneBytes += size
+	p.current = e.Path
+	if e.Type == events.ItemDeleted {
+		p.freedBytes += size
+	}
+}
+
+// percent is the share of items handled, weighted by size when the sizes are known
+func (p deleteProgress) percent() float64 {
+	if p.totalItems == 0 {
+		return 1
+	}
+	if p.totalBytes == 0 {
+		return float64(p.doneItems) / float64(p.totalItems)
+	}
+	return float64(p.doneBytes) / float64(p.totalBytes)
+}
+
+// deletingView renders the progress screen
+func (m Model) deletingView() string {
+	var s strings.Builder
+	p := m.progressInfo
+	if m.cancelling {
+		s.WriteString(titleStyle.Render("Cancelling after the current item...") + "\n\n")
+	} else {
+		s.WriteString(titleStyle.Render("Deleting files...") + "\n\n")
+	}
+	s.WriteString(m.progress.View() + "\n\n")
+	s.WriteString(fmt.Sprintf("%d of %d items, %s of %s freed\n", p.doneItems, p.totalItems, units.FormatBytes(p.freedBytes), units.FormatBytes(p.totalBytes)))
+	if p.current != "" {
+		s.WriteString(fmt.Sprintf("Last: %s\n", p.current))
+	}
+	return s.String()
+}
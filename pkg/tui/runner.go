@@ -1,14 +1,41 @@
 package tui
 
 import (
+	"errors"
 	"fmt"
 
+	"github.com/alexintosh/gocleaner/pkg/cleaner"
+	"github.com/alexintosh/gocleaner/pkg/finder"
 	tea "github.com/charmbracelet/bubbletea"
 )
 
-// RunTUI launches the TUI for the app uninstall process
-func RunTUI(appName string, dryRun, force, verbose bool) error {
-	model := NewModel(appName, dryRun, force, verbose)
+// Options controls how the TUI scans for and deletes files
+type Options struct {
+	DryRun  bool
+	Force   bool
+	Verbose bool
+
+	// Categories limits the scan to these categories; nil means all of them
+	Categories []finder.Category
+
+	// Include holds paths added by hand; they are listed with the files found
+	// for the first app
+	Include []finder.Match
+
+	// Keys replaces the default key bindings
+	Keys *KeyMap
+
+	// AllowForeignOwner also deletes items owned by other users
+	AllowForeignOwner bool
+
+	// Privileged deletes the selected files that need root; without it they
+	// are deleted directly
+	Privileged cleaner.PrivilegedDeleter
+}
+
+// RunTUI launches the TUI for the uninstall process of one or more apps
+func RunTUI(appNames []string, opts Options) error {
+	model := NewModel(appNames, opts)
 	
 	p := tea.NewProgram(model, tea.WithAltScreen())
 	m, err := p.Run()
@@ -21,7 +48,7 @@ func RunTUI(appName string, dryRun, force, verbose bool) error {
 	
 	// If there was an error, return it
 	if finalModel.errorMsg != "" {
-		return fmt.Errorf(finalModel.errorMsg)
+		return errors.New(finalModel.errorMsg)
 	}
 	
 	return nil
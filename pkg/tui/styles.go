@@ -36,6 +36,11 @@ var (
 		BorderForeground(subtle).
 		Padding(0, 1)
 
+	detailStyle = lipgloss.NewStyle().
+		Border(lipgloss.RoundedBorder()).
+		BorderForeground(subtle).
+		Padding(0, 1)
+
 	selectedItemStyle = lipgloss.NewStyle().
 		Foreground(highlight).
 		Bold(true)
@@ -44,6 +49,10 @@ var (
 		SetString("[✓] ").
 		Foreground(special)
 
+	checkboxPartial = lipgloss.NewStyle().
+		SetString("[-] ").
+		Foreground(highlight)
+
 	checkboxUnchecked = lipgloss.NewStyle().
 		SetString("[ ] ").
 		Foreground(subtle)
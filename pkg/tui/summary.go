@@ -0,0 +1,137 @@
+package tui
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/alexintosh/gocleaner/pkg/events"
+	"github.com/alexintosh/gocleaner/pkg/units"
+	"github.com/charmbracelet/bubbles/key"
+	"github.com/charmbracelet/bubbles/viewport"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// outcomeItem is a handled item with the reason it was skipped or the error
+// that made it fail
+type outcomeItem struct {
+	path   string
+	detail string
+}
+
+// deleteOutcome collects what happened to every item for the summary
+type deleteOutcome struct {
+	deleted []outcomeItem
+	skipped []outcomeItem
+	failed  []outcomeItem
+}
+
+// record files the item of a deleted, skipped or failed event
+func (o *deleteOutcome) record(e events.Event) {
+	switch e.Type {
+	case events.ItemDeleted:
+		o.deleted = append(o.deleted, outcomeItem{path: e.Path})
+	case events.ItemSkipped:
+		o.skipped = append(o.skipped, outcomeItem{path: e.Path, detail: e.Reason})
+	case events.ItemFailed:
+		o.failed = append(o.failed, outcomeItem{path: e.Path, detail: e.Error})
+	}
+}
+
+// showSummary switches to the summary screen, which stays open until dismissed
+func (m Model) showSummary() (tea.Model, tea.Cmd) {
+	m.state = stateSummary
+	m.summary = viewport.New(m.width-8, m.height-8)
+	m.summary.KeyMap.Up = m.keys.Up
+	m.summary.KeyMap.Down = m.keys.Down
+	m.summary.KeyMap.PageUp = m.keys.PrevPage
+	m.summary.KeyMap.PageDown = m.keys.NextPage
+	m.summary.SetContent(m.summaryContent())
+	return m, nil
+}
+
+// updateSummary scrolls the summary or closes it
+func (m Model) updateSummary(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	if msg.String() == "ctrl+c" || key.Matches(msg, m.keys.Quit, m.keys.ClearFilter, m.keys.Confirm) {
+		m.state = stateDone
+		m.statusMsg = fmt.Sprintf("Successfully deleted %d files.", len(m.outcome.deleted))
+		if m.cancelled {
+			m.statusMsg = fmt.Sprintf("Cancelled after deleting %d of %d files.", len(m.outcome.deleted), len(m.selectedFiles))
+		}
+		return m, tea.Quit
+	}
+
+	var cmd tea.Cmd
+	m.summary, cmd = m.summary.Update(msg)
+	return m, cmd
+}
+
+// summaryContent lists every handled item under its outcome
+func (m Model) summaryContent() string {
+	var s strings.Builder
+	o := m.outcome
+
+	s.WriteString(statusMessageStyle.Render(fmt.Sprintf("%d deleted, %d skipped, %d failed, %s freed",
+		len(o.deleted), len(o.skipped), len(o.failed), units.FormatBytes(m.progressInfo.freedBytes))) + "\n")
+	s.WriteString("Deleted items are removed permanently; nothing was moved to the Trash or backed up.\n")
+
+	// A cancelled run leaves some of the selection untouched
+	if m.cancelled {
+		remaining := m.notHandled()
+		s.WriteString("\n" + errorStyle.Render(fmt.Sprintf("Cancelled, %d items were not removed", len(remaining))) + "\n")
+		for _, p := range remaining {
+			s.WriteString("  " + p + "\n")
+		}
+	}
+
+	if len(o.failed) > 0 {
+		s.WriteString("\n" + errorStyle.Render(fmt.Sprintf("Failed (%d)", len(o.failed))) + "\n")
+		for _, item := range o.failed {
+			s.WriteString(fmt.Sprintf("  %s\n    %s\n", item.path, item.detail))
+		}
+	}
+	if len(o.skipped) > 0 {
+		s.WriteString("\n" + titleStyle.Render(fmt.Sprintf("Skipped (%d)", len(o.skipped))) + "\n")
+		for _, item := range o.skipped {
+			s.WriteString(fmt.Sprintf("  %s\n    %s\n", item.path, item.detail))
+		}
+	}
+	if len(o.deleted) > 0 {
+		s.WriteString("\n" + titleStyle.Render(fmt.Sprintf("Deleted (%d)", len(o.deleted))) + "\n")
+		for _, item := range o.deleted {
+			s.WriteString(fmt.Sprintf("  %s  %s\n", item.path, units.FormatBytes(m.confirm.sizes[item.path])))
+		}
+	}
+	return s.String()
+}
+
+// notHandled lists the selected items the deletion never got to
+func (m Model) notHandled() []string {
+	handled := map[string]bool{}
+	for _, items := range [][]outcomeItem{m.outcome.deleted, m.outcome.skipped, m.outcome.failed} {
+		for _, item := range items {
+			handled[item.path] = true
+		}
+	}
+
+	remaining := []string{}
+	for _, p := range m.selectedFiles {
+		if !handled[p] {
+			remaining = append(remaining, p)
+		}
+	}
+	return remaining
+}
+
+// summaryView renders the summary screen
+func (m Model) summaryView() string {
+	var s strings.Builder
+	title := fmt.Sprintf("Summary for %s", m.appLabel())
+	if m.cancelled {
+		title += " (cancelled)"
+	}
+	s.WriteString(titleStyle.Render(title) + "\n\n")
+	s.WriteString(m.summary.View() + "\n\n")
+	s.WriteString(fmt.Sprintf("%3.f%% · %s/%s to scroll, %s or %s to close\n", m.summary.ScrollPercent()*100,
+		m.keys.Up.Help().Key, m.keys.Down.Help().Key, m.keys.Quit.Help().Key, m.keys.Confirm.Help().Key))
+	return s.String()
+}
@@ -0,0 +1,236 @@
+package tui
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/alexintosh/gocleaner/pkg/finder"
+	"github.com/alexintosh/gocleaner/pkg/paths"
+	"github.com/alexintosh/gocleaner/pkg/units"
+	"github.com/charmbracelet/bubbles/list"
+)
+
+// Groups with more files than this start collapsed
+const collapseAbove = 10
+
+// fileGroup holds the files of one category under one root, and of one app
+// when several are uninstalled at once
+type fileGroup struct {
+	app      string
+	category finder.Category
+	root     string
+	files    []finder.Match
+	size     int64
+	expanded bool
+}
+
+// fileTree is the grouped view of the found files along with which of them
+// are selected, how they are filtered and in which order they are shown.
+// The list shows the rows it generates.
+type fileTree struct {
+	groups   []*fileGroup
+	selected map[string]bool
+	sizes    map[string]int64
+	batch    bool
+	filter   string
+	sortBy   sortKey
+}
+
+// newFileTree groups the matches of every app by category and root, in app
+// order and then in the order categories are listed in finder.Categories.
+// Every file starts selected.
+func newFileTree(results []finder.AppMatches, sizes map[string]int64, batch bool) *fileTree {
+	t := &fileTree{selected: map[string]bool{}, sizes: sizes, batch: batch, sortBy: sortCategory}
+
+	for _, result := range results {
+		byKey := map[string]*fileGroup{}
+		appGroups := []*fileGroup{}
+		for _, match := range result.Matches {
+			key := string(match.Category) + "\x00" + match.Root
+			group, ok := byKey[key]
+			if !ok {
+				group = &fileGroup{app: result.App, category: match.Category, root: match.Root}
+				byKey[key] = group
+				appGroups = append(appGroups, group)
+			}
+			group.files = append(group.files, match)
+			group.size += sizes[match.Path]
+			t.selected[match.Path] = true
+		}
+
+		for _, category := range finder.Categories {
+			for _, group := range appGroups {
+				if group.category == category {
+					group.expanded = len(group.files) <= collapseAbove
+					t.groups = append(t.groups, group)
+				}
+			}
+		}
+	}
+	return t
+}
+
+// items returns the list rows: every group with a file passing the filter,
+// followed by those files when expanded
+func (t *fileTree) items() []list.Item {
+	items := []list.Item{}
+	for _, group := range t.visibleGroups() {
+		items = append(items, groupItem{group: group, tree: t})
+		if !group.expanded {
+			continue
+		}
+		for _, match := range t.visibleFiles(group) {
+			items = append(items, FileItem{
+				path:     match.Path,
+				app:      group.app,
+				match:    match,
+				size:     t.sizes[match.Path],
+				selected: t.selected[match.Path],
+			})
+		}
+	}
+	return items
+}
+
+// toggle flips the selection of a file, or of every file of a group that
+// passes the filter
+func (t *fileTree) toggle(item list.Item) {
+	switch item := item.(type) {
+	case FileItem:
+		t.selected[item.path] = !t.selected[item.path]
+	case groupItem:
+		files := t.visibleFiles(item.group)
+		selectAll := item.selectedCount() < len(files)
+		for _, match := range files {
+			t.selected[match.Path] = selectAll
+		}
+	}
+}
+
+// setAll selects or deselects every file passing the filter. Files hidden
+// by the filter keep their selection.
+func (t *fileTree) setAll(selected bool) {
+	for _, group := range t.groups {
+		for _, match := range t.visibleFiles(group) {
+			t.selected[match.Path] = selected
+		}
+	}
+}
+
+// setExpanded expands or collapses every group
+func (t *fileTree) setExpanded(expanded bool) {
+	for _, group := range t.groups {
+		group.expanded = expanded
+	}
+}
+
+// add selects a file added by hand, putting it in the group of its app,
+// category and root. A new group is expanded and goes last.
+func (t *fileTree) add(app string, match finder.Match, size int64) {
+	t.sizes[match.Path] = size
+	t.selected[match.Path] = true
+	for _, group := range t.groups {
+		if group.app == app && group.category == match.Category && group.root == match.Root {
+			group.files = append(group.files, match)
+			group.size += size
+			group.expanded = true
+			return
+		}
+	}
+	t.groups = append(t.groups, &fileGroup{app: app, category: match.Category, root: match.Root, files: []finder.Match{match}, size: size, expanded: true})
+}
+
+// selectedMatches returns the selected files in list order
+func (t *fileTree) selectedMatches() []finder.Match {
+	matches := []finder.Match{}
+	for _, group := range t.groups {
+		for _, match := range group.files {
+			if t.selected[match.Path] {
+				matches = append(matches, match)
+			}
+		}
+	}
+	return matches
+}
+
+// groupIndex returns the row of the group a file belongs to
+func groupIndex(items []list.Item, path string) int {
+	group := -1
+	for i, item := range items {
+		switch item := item.(type) {
+		case groupItem:
+			group = i
+		case FileItem:
+			if item.path == path {
+				return group
+			}
+		}
+	}
+	return -1
+}
+
+// groupItem is the row heading a group
+type groupItem struct {
+	group *fileGroup
+	tree  *fileTree
+}
+
+// selectedCount returns how many files of the group passing the filter are
+// selected
+func (i groupItem) selectedCount() int {
+	n := 0
+	for _, match := range i.tree.visibleFiles(i.group) {
+		if i.tree.selected[match.Path] {
+			n++
+		}
+	}
+	return n
+}
+
+func (i groupItem) Title() string {
+	checkbox := checkboxUnchecked.String()
+	switch n := i.selectedCount(); {
+	case n == len(i.tree.visibleFiles(i.group)):
+		checkbox = checkboxChecked.String()
+	case n > 0:
+		checkbox = checkboxPartial.String()
+	}
+
+	arrow := "▸ "
+	if i.group.expanded {
+		arrow = "▾ "
+	}
+
+	label := fmt.Sprintf("%s  %s", i.group.category, shortenHome(i.group.root))
+	if i.tree.batch {
+		label = i.group.app + " · " + label
+	}
+	return checkbox + arrow + label
+}
+
+// Description sums up the group's files and how many of them are selected.
+// Under a filter only the matching files are counted.
+func (i groupItem) Description() string {
+	shown := len(i.tree.visibleFiles(i.group))
+	if shown < len(i.group.files) {
+		return fmt.Sprintf("    %d of %d items, %s, %d selected", shown, len(i.group.files), units.FormatBytes(i.tree.visibleSize(i.group)), i.selectedCount())
+	}
+	return fmt.Sprintf("    %d items, %s, %d selected", len(i.group.files), units.FormatBytes(i.group.size), i.selectedCount())
+}
+
+func (i groupItem) FilterValue() string {
+	return string(i.group.category) + " " + i.group.root
+}
+
+// shortenHome abbreviates the home directory being cleaned to ~
+func shortenHome(path string) string {
+	if home := paths.Home(); home != "" {
+		if path == home {
+			return "~"
+		}
+		if rest, ok := strings.CutPrefix(path, home+"/"); ok {
+			return "~/" + rest
+		}
+	}
+	return path
+}
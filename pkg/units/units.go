@@ -0,0 +1,83 @@
+package units
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// FormatBytes renders a byte count using binary units, e.g. "12.3 MB"
+func FormatBytes(size int64) string {
+	const unit = 1024
+	if size < unit {
+		return fmt.Sprintf("%d B", size)
+	}
+
+	div, exp := int64(unit), 0
+	for n := size / unit; n >= unit; n /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %cB", float64(size)/float64(div), "KMGTPE"[exp])
+}
+
+// byteUnits maps the suffixes accepted by ParseBytes to their multipliers
+var byteUnits = []struct {
+	suffix string
+	size   int64
+}{
+	{"TB", 1 << 40}, {"GB", 1 << 30}, {"MB", 1 << 20}, {"KB", 1 << 10},
+	{"T", 1 << 40}, {"G", 1 << 30}, {"M", 1 << 20}, {"K", 1 << 10},
+	{"B", 1},
+}
+
+// ParseBytes parses a size such as "500", "200K", "1.5GB" or "10 MB"
+func ParseBytes(s string) (int64, error) {
+	value := strings.ToUpper(strings.TrimSpace(s))
+	multiplier := int64(1)
+	for _, u := range byteUnits {
+		if strings.HasSuffix(value, u.suffix) {
+			value = strings.TrimSpace(strings.TrimSuffix(value, u.suffix))
+			multiplier = u.size
+			break
+		}
+	}
+
+	n, err := strconv.ParseFloat(value, 64)
+	if err != nil || n < 0 {
+		return 0, fmt.Errorf("invalid size %q (examples: 500K, 100MB, 1.5G)", s)
+	}
+	return int64(n * float64(multiplier)), nil
+}
+
+// ParseAge parses a duration that, besides everything time.ParseDuration
+// accepts, may use days and weeks, e.g. "30d" or "2w"
+func ParseAge(s string) (time.Duration, error) {
+	value := strings.TrimSpace(s)
+	for suffix, unit := range map[string]time.Duration{"d": 24 * time.Hour, "w": 7 * 24 * time.Hour} {
+		if n, ok := strings.CutSuffix(value, suffix); ok {
+			if count, err := strconv.ParseFloat(n, 64); err == nil && count >= 0 {
+				return time.Duration(count * float64(unit)), nil
+			}
+		}
+	}
+
+	d, err := time.ParseDuration(value)
+	if err != nil || d < 0 {
+		return 0, fmt.Errorf("invalid age %q (examples: 12h, 30d, 2w)", s)
+	}
+	return d, nil
+}
+
+// FormatAge renders a duration in its largest whole unit, e.g. "3d" or "5h"
+func FormatAge(d time.Duration) string {
+	switch {
+	case d >= 24*time.Hour:
+		return fmt.Sprintf("%dd", int(d/(24*time.Hour)))
+	case d >= time.Hour:
+		return fmt.Sprintf("%dh", int(d/time.Hour))
+	default:
+		return fmt.Sprintf("%dm", int(d/time.Minute))
+	}
+}